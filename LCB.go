@@ -9,91 +9,225 @@ import (
 	"net/http"
 	"reflect"
 	"sync"
+	"time"
 )
 
 // Пример использования
-// state := NewNewState[*T]()
-// state.Append(key, &element)
-// element := *state.GetElementByKey(key)
+// state := NewNewState[int64, *T]()
+// state.SetElement(userID, &element)
+// key, err := state.AddElement(&element) // ключ выдаёт генератор ID
+// element := state.GetElement(userID)
 
-type NewState[T any] struct {
-	Mu sync.Mutex
-	State map[int64]T
-	Count int64
-	WarningZone []int64
+// NewState - потокобезопасное хранилище элементов по ключу. Ключи для AddElement
+// выдаёт IDGenerator: для int64 по умолчанию SequentialIDs в диапазоне
+// [DefaultStateIDStart, DefaultStateIDEnd], для string - UUIDs. SetElement и AddElement
+// делят одно пространство ключей: ключи, записанные через SetElement, генератор пропускает.
+// Чтение идёт под RLock, поэтому параллельные GetElement не блокируют друг друга.
+type NewState[K comparable, V any] struct {
+	Mu sync.RWMutex
+	State map[K]V
+	ids IDGenerator[K]
+	storage Storage[K, V]
+	indexes map[string]*stateIndex[K, V]
+	ev evictor[K, V]
 }
 
-func NewNewState[T any]() *NewState[T] {
-	return &NewState[T]{
-		State: make(map[int64]T),
-		Mu: sync.Mutex{},
-		Count: -99999999999999,
-		WarningZone: []int64{-99999999999999, -90000000000001},
+func NewNewState[K comparable, V any]() *NewState[K, V] {
+	return &NewState[K, V]{
+		State: make(map[K]V),
+		ids: defaultIDs[K](),
 	}
 }
 
-func (ns *NewState[T]) GetElement(key int64) T {
+// NewNewStateWithStorage создаёт состояние, загружая элементы из storage.
+// Все изменения затем записываются в storage.
+func NewNewStateWithStorage[K comparable, V any](storage Storage[K, V]) (*NewState[K, V], error) {
+	elements, err := storage.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	ns := NewNewState[K, V]()
+	ns.storage = storage
+	for key, element := range elements {
+		ns.State[key] = element
+	}
+	ns.observeKeys()
+
+	return ns, nil
+}
+
+// SetIDGenerator задаёт генератор ключей для AddElement.
+func (ns *NewState[K, V]) SetIDGenerator(ids IDGenerator[K]) {
 	ns.Mu.Lock()
-	element := ns.State[key]
+	ns.ids = ids
+	ns.observeKeys()
 	ns.Mu.Unlock()
+}
 
+// observeKeys сообщает генератору уже занятые ключи, чтобы он не выдал их повторно.
+func (ns *NewState[K, V]) observeKeys() {
+	for key := range ns.State {
+		ns.observeKey(key)
+	}
+}
+
+// observeKey сообщает генератору занятый ключ. Вызывается под ns.Mu.
+func (ns *NewState[K, V]) observeKey(key K) {
+	if observer, ok := ns.ids.(idObserver[K]); ok {
+		observer.Observe(key)
+	}
+}
+
+// Close останавливает janitor, сохраняет несохранённые изменения и закрывает хранилище.
+func (ns *NewState[K, V]) Close() error {
+	ns.StopJanitor()
+	if ns.storage == nil {
+		return nil
+	}
+	return ns.storage.Close()
+}
+
+func (ns *NewState[K, V]) GetElement(key K) V {
+	element, _ := ns.GetElementOk(key)
 	return element
 }
 
-func (ns *NewState[T]) SetElement(key int64, element T) {
-	if ns.WarningZone[0] < key && key < ns.WarningZone[1] {
-		fmt.Print("WARNING, the value of key ran out of SAFE zone, Warning Zone: -99999999999999 : -90000000000001")
+// GetElementOk возвращает элемент и признак того, что он есть в состоянии.
+func (ns *NewState[K, V]) GetElementOk(key K) (V, bool) {
+	ns.Mu.RLock()
+	defer ns.Mu.RUnlock()
+
+	element, ok := ns.State[key]
+	if !ok || ns.expired(key, time.Now()) {
+		var zero V
+		return zero, false
 	}
+	ns.touch(key)
+	return element, true
+}
+
+func (ns *NewState[K, V]) SetElement(key K, element V) {
+	if err := ns.Put(key, element); err != nil {
+		fmt.Println("Error setting state element:", err)
+	}
+}
+
+// Put сохраняет элемент под key. В отличие от SetElement возвращает
+// ErrUniqueViolation, если элемент нарушает уникальный индекс.
+func (ns *NewState[K, V]) Put(key K, element V) error {
 	ns.Mu.Lock()
+	defer ns.unlockEvicted()
+
+	return ns.put(key, element)
+}
+
+// put записывает элемент в State, индексы и хранилище. Вызывается под ns.Mu.
+func (ns *NewState[K, V]) put(key K, element V) error {
+	if ns.expired(key, time.Now()) {
+		ns.evict(key, EvictExpired)
+	}
+	if err := ns.checkIndexes(key, element); err != nil {
+		return err
+	}
 	ns.State[key] = element
-	ns.Mu.Unlock()
+	ns.observeKey(key)
+	ns.reindex(key, element)
+	ns.persist(key, element)
+	ns.tracked(key)
+	return nil
 }
 
-func (ns *NewState[T]) AddElement(element T) {
-	if ns.WarningZone[0] > ns.Count && ns.Count > ns.WarningZone[1] {
-		fmt.Print("WARNING, the value of ns.Count ran out of Warning zone, Warning Zone: -99999999999999 : -90000000000001")
+// AddElement сохраняет элемент под ключом от генератора ID и возвращает этот ключ.
+// Возвращает ErrNoIDGenerator, ErrIDOverflow или ErrIDCollision.
+func (ns *NewState[K, V]) AddElement(element V) (K, error) {
+	ns.Mu.Lock()
+	defer ns.unlockEvicted()
+
+	var key K
+	if ns.ids == nil {
+		return key, ErrNoIDGenerator
 	}
-	ns.Count += 1
+	key, err := ns.ids.NextID()
+	if err != nil {
+		return key, err
+	}
+	if _, exists := ns.State[key]; exists {
+		return key, fmt.Errorf("%w: %v", ErrIDCollision, key)
+	}
+	return key, ns.put(key, element)
+}
+
+func (ns *NewState[K, V]) DeleteElement(key K) {
 	ns.Mu.Lock()
-	ns.State[ns.Count] = element
+	ns.remove(key)
 	ns.Mu.Unlock()
 }
 
-func (ns *NewState[T]) DeleteElement(key int64) {
-	ns.Mu.Lock()
+// remove удаляет элемент из State, индексов и хранилища. Вызывается под ns.Mu.
+func (ns *NewState[K, V]) remove(key K) {
+	if _, ok := ns.State[key]; !ok {
+		return
+	}
 	delete(ns.State, key)
-	ns.Mu.Unlock()
+	ns.unindex(key)
+	ns.untrack(key)
+	if ns.storage != nil {
+		if err := ns.storage.Delete(key); err != nil {
+			fmt.Println("Error deleting state element from storage:", err)
+		}
+	}
 }
 
-func (ns *NewState[T]) GetKeyByNameFieldAndVal(nameField string, valueTarget any) (int64, error) {
-	var result int64
-	var er error
-	var flag bool
+// persist записывает элемент в хранилище. Вызывается под ns.Mu.
+func (ns *NewState[K, V]) persist(key K, element V) {
+	if ns.storage == nil {
+		return
+	}
+	if err := ns.storage.Set(key, element); err != nil {
+		fmt.Println("Error saving state element to storage:", err)
+	}
+}
 
-	ns.Mu.Lock()
+// GetKeyByNameFieldAndVal возвращает ключ элемента, у которого поле nameField
+// равно valueTarget. Если объявлен AddFieldIndex(nameField, ...), поиск идёт по индексу,
+// иначе перебором всех элементов. При нескольких совпадениях ключ любой из них,
+// все ключи возвращает Lookup.
+func (ns *NewState[K, V]) GetKeyByNameFieldAndVal(nameField string, valueTarget any) (K, error) {
+	var result K
+
+	ns.Mu.RLock()
+	defer ns.Mu.RUnlock()
+
+	now := time.Now()
+	if index, ok := ns.fieldIndex(nameField); ok {
+		for key := range index.entries[indexValue(valueTarget)] {
+			if !ns.expired(key, now) {
+				return key, nil
+			}
+		}
+		return result, ErrElementNotFound
+	}
+
+	target := indexValue(valueTarget)
 	for key, valueT := range ns.State {
+		if ns.expired(key, now) {
+			continue
+		}
 		value, err := ns.getFieldValue(valueT, nameField)
 		if err != nil {
-			er = err
-			break
+			return result, err
 		}
 
-		if value == valueTarget {
-			result = key
-			flag = true
-			break
+		if indexValue(value) == target {
+			return key, nil
 		}
 	}
-	ns.Mu.Unlock()
 
-	if !flag {
-		er = fmt.Errorf("значение не найдено")
-	}
-
-	return result, er
+	return result, ErrElementNotFound
 }
 
-func (ns *NewState[T]) getFieldValue(obj T, fieldName string) (any, error) {
+func (ns *NewState[K, V]) getFieldValue(obj V, fieldName string) (any, error) {
 	v := reflect.ValueOf(obj)
 
 	if v.Kind() == reflect.Ptr {
@@ -112,21 +246,54 @@ func (ns *NewState[T]) getFieldValue(obj T, fieldName string) (any, error) {
 	return field.Interface(), nil
 }
 
+// AddHandler регистрирует обработчик в группе по умолчанию.
+// В группе срабатывает только первый обработчик, чей фильтр подошёл.
 func (b *Bot) AddHandler(filter func(update Update) bool, callback func(update Update)) {
-	b.handlers = append(b.handlers, Handler{Filter: filter, Callback: callback})
+	b.defaultGroup.AddHandler(filter, callback)
 }
 
 func (b *Bot) Start() {
+	if b.Username == "" {
+		me, err := b.GetMe()
+		if err != nil {
+			fmt.Println("Error getting bot info:", err)
+		} else {
+			b.Username = me.Username
+		}
+	}
+	b.started = true
+	b.dispatcher = newDispatcher(b.dispatcherConfig)
 	go b.pollUpdates()
 	go b.processUpdates()
 }
 
+// Stop прекращает получение обновлений и ждёт завершения уже запущенных обработчиков.
+// Контекст, переданный обработчикам, отменяется.
+func (b *Bot) Stop() {
+	b.cancel()
+	if b.started {
+		<-b.done
+	}
+}
+
 func (b *Bot) pollUpdates() {
 	defer close(b.updatesChan)
 	for {
+		if b.ctx.Err() != nil {
+			return
+		}
+
 		updates, err := b.getUpdates(b.lastUpdateId)
 		if err != nil {
+			if b.ctx.Err() != nil {
+				return
+			}
 			fmt.Println("Error getting updates:", err)
+			select {
+			case <-b.ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
 			continue
 		}
 
@@ -134,24 +301,33 @@ func (b *Bot) pollUpdates() {
 			if b.lastUpdateId <= update.UpdateID {
 				b.lastUpdateId = update.UpdateID + 1
 			}
-			b.updatesChan <- update
+			select {
+			case b.updatesChan <- update:
+			case <-b.ctx.Done():
+				return
+			}
 		}
 	}
 }
 
 func (b *Bot) processUpdates() {
+	defer close(b.done)
 	for update := range b.updatesChan {
-		for _, handler := range b.handlers {
-			if handler.Filter(update) {
-				go handler.Callback(update)
-			}
-		}
+		b.dispatch(update)
 	}
+	if b.dispatcher != nil {
+		b.dispatcher.close()
+	}
+	b.running.Wait()
 }
 
 func (b *Bot) getUpdates(offset int64) ([]Update, error) {
 	url := fmt.Sprintf("https://api.telegram.org/bot%s/getUpdates?offset=%d", b.Token, offset)
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(b.ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		fmt.Println(err)
 		return nil, err
@@ -177,7 +353,7 @@ func (b *Bot) getUpdates(offset int64) ([]Update, error) {
 	if len(updates.Result) > 0 && b.logs {
 		var formattedJSON bytes.Buffer
 		if err := json.Indent(&formattedJSON, body, "", "  "); err != nil {
-			fmt.Println("Ошибка форматирования JSON: %v", err)
+			fmt.Printf("Ошибка форматирования JSON: %v\n", err)
 			return nil, fmt.Errorf("Ошибка форматирования JSON")
 		}
 		fmt.Println(formattedJSON.String())
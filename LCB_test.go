@@ -0,0 +1,121 @@
+package LCB
+
+import (
+	"sync"
+	"testing"
+)
+
+const (
+	raceWorkers    = 32
+	raceIterations = 500
+)
+
+func TestNewStateConcurrentAddGetDelete(t *testing.T) {
+	ns := NewNewState[int64, int]()
+
+	var mu sync.Mutex
+	seen := make(map[int64]bool)
+	var wg sync.WaitGroup
+	for w := 0; w < raceWorkers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < raceIterations; i++ {
+				key, err := ns.AddElement(w*raceIterations + i)
+				if err != nil {
+					t.Errorf("AddElement: %v", err)
+					return
+				}
+
+				mu.Lock()
+				if seen[key] {
+					t.Errorf("AddElement returned key %d twice", key)
+				}
+				seen[key] = true
+				mu.Unlock()
+
+				if element, ok := ns.GetElementOk(key); !ok || element != w*raceIterations+i {
+					t.Errorf("GetElementOk(%d) = %d, %v", key, element, ok)
+				}
+				if i%2 == 0 {
+					ns.DeleteElement(key)
+					if _, ok := ns.GetElementOk(key); ok {
+						t.Errorf("element %d is still present after DeleteElement", key)
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if len(seen) != raceWorkers*raceIterations {
+		t.Fatalf("got %d unique keys, want %d", len(seen), raceWorkers*raceIterations)
+	}
+	if len(ns.State) != raceWorkers*raceIterations/2 {
+		t.Fatalf("got %d elements left, want %d", len(ns.State), raceWorkers*raceIterations/2)
+	}
+}
+
+// Чтение при включённом MaxSize двигает элемент в LRU-списке под RLock.
+func TestNewStateConcurrentTouch(t *testing.T) {
+	const size = 64
+
+	ns := NewNewState[int64, int]()
+	ns.SetMaxSize(size)
+	for i := int64(0); i < size; i++ {
+		ns.SetElement(i, int(i))
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < raceWorkers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < raceIterations; i++ {
+				ns.GetElement(int64((w + i) % size))
+				if w == 0 && i%10 == 0 {
+					if _, err := ns.AddElement(i); err != nil {
+						t.Errorf("AddElement: %v", err)
+						return
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if len(ns.State) > size {
+		t.Fatalf("got %d elements, MaxSize is %d", len(ns.State), size)
+	}
+	if ns.ev.lru.Len() != len(ns.State) || len(ns.ev.lruItems) != len(ns.State) {
+		t.Fatalf("LRU tracks %d/%d keys, state has %d", ns.ev.lru.Len(), len(ns.ev.lruItems), len(ns.State))
+	}
+}
+
+func TestSequentialIDsConcurrent(t *testing.T) {
+	ids := NewSequentialIDs(1)
+
+	var mu sync.Mutex
+	seen := make(map[int64]bool)
+	var wg sync.WaitGroup
+	for w := 0; w < raceWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < raceIterations; i++ {
+				id, err := ids.NextID()
+				if err != nil {
+					t.Errorf("NextID: %v", err)
+					return
+				}
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("NextID returned %d twice", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,243 @@
+package LCB
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const calendarPrefix = "cal:"
+
+// CalendarLocale содержит названия месяцев и дней недели для календаря.
+type CalendarLocale struct {
+	Months   [12]string
+	Weekdays [7]string // Начиная с воскресенья, как time.Weekday
+	FirstDay time.Weekday
+	Back     string
+}
+
+// CalendarLocales - встроенные локали календаря.
+var CalendarLocales = map[string]*CalendarLocale{
+	"ru": {
+		Months:   [12]string{"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь", "Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь"},
+		Weekdays: [7]string{"Вс", "Пн", "Вт", "Ср", "Чт", "Пт", "Сб"},
+		FirstDay: time.Monday,
+		Back:     "« Назад",
+	},
+	"en": {
+		Months:   [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		Weekdays: [7]string{"Su", "Mo", "Tu", "We", "Th", "Fr", "Sa"},
+		FirstDay: time.Sunday,
+		Back:     "« Back",
+	},
+}
+
+// Пример использования
+// cal := bot.NewCalendar("book", func(update Update, date time.Time) {
+// 	chatID := update.CallbackQuery.Message.Chat.ID
+// 	bot.SendMessage(chatID, "Вы выбрали "+date.Format("02.01.2006 15:04"), Utils{})
+// })
+// cal.WithTime = true
+// cal.Min = time.Now()
+// cal.Send(chatID, "Выберите дату", time.Now())
+
+// Calendar выводит месяц в виде inline-клавиатуры с навигацией по месяцам
+// и, при WithTime, выбором часа и минут. Выбранная дата передаётся в OnSelect.
+type Calendar struct {
+	bot *Bot
+	id  string
+
+	// Min и Max ограничивают выбор. Нулевое значение - без ограничения.
+	Min time.Time
+	Max time.Time
+	// Locale задаёт названия месяцев и дней недели, по умолчанию "ru".
+	Locale *CalendarLocale
+	// Location - часовой пояс выбранной даты, по умолчанию time.Local.
+	Location *time.Location
+	// WithTime включает выбор времени после выбора дня.
+	WithTime bool
+	// MinuteStep - шаг минут в выборе времени, по умолчанию 15.
+	MinuteStep int
+	// OnSelect вызывается с выбранной датой.
+	OnSelect func(update Update, date time.Time)
+}
+
+// NewCalendar создаёт календарь и регистрирует обработчик его кнопок.
+// id попадает в callback_data и должен быть коротким и уникальным среди календарей бота.
+func (b *Bot) NewCalendar(id string, onSelect func(update Update, date time.Time)) *Calendar {
+	c := &Calendar{
+		bot:        b,
+		id:         id,
+		Locale:     CalendarLocales["ru"],
+		Location:   time.Local,
+		MinuteStep: 15,
+		OnSelect:   onSelect,
+	}
+	b.handleWidget(c.isCalendar, func(ctx *Ctx) { c.handle(ctx.Update) })
+	return c
+}
+
+// Send отправляет календарь на месяц, содержащий month, и возвращает ID сообщения.
+func (c *Calendar) Send(chatID int64, text string, month time.Time) int {
+	return c.bot.SendMessage(chatID, text, Utils{Inline: c.Render(month)})
+}
+
+// Render возвращает клавиатуру месяца, содержащего month.
+func (c *Calendar) Render(month time.Time) *InlineKeyboardMarkup {
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, c.Location)
+	prev := first.AddDate(0, -1, 0)
+	next := first.AddDate(0, 1, 0)
+
+	keyboard := NewInlineKeyboard()
+
+	header := []InlineKeyboardButton{c.ignore(" "), c.ignore(fmt.Sprintf("%s %d", c.Locale.Months[first.Month()-1], first.Year())), c.ignore(" ")}
+	if c.rangeAllowed(prev, first) {
+		header[0] = InlineCallback("‹", c.data("m", prev.Format("200601")))
+	}
+	if c.rangeAllowed(next, next.AddDate(0, 1, 0)) {
+		header[2] = InlineCallback("›", c.data("m", next.Format("200601")))
+	}
+	keyboard.Row(header...)
+
+	weekdays := make([]InlineKeyboardButton, 7)
+	for i := range weekdays {
+		weekdays[i] = c.ignore(c.Locale.Weekdays[(int(c.Locale.FirstDay)+i)%7])
+	}
+	keyboard.Row(weekdays...)
+
+	// Пустая строка нужна, чтобы Add начал дни с новой строки, а не дописывал их к заголовку.
+	keyboard.Width(7).Row()
+	offset := (int(first.Weekday()) - int(c.Locale.FirstDay) + 7) % 7
+	for i := 0; i < offset; i++ {
+		keyboard.Add(c.ignore(" "))
+	}
+	for day := first; day.Before(next); day = day.AddDate(0, 0, 1) {
+		if c.dayAllowed(day) {
+			keyboard.Add(InlineCallback(fmt.Sprint(day.Day()), c.data("d", day.Format("20060102"))))
+		} else {
+			keyboard.Add(c.ignore("·"))
+		}
+	}
+	for (offset+next.AddDate(0, 0, -1).Day())%7 != 0 {
+		keyboard.Add(c.ignore(" "))
+		offset++
+	}
+
+	return c.build(keyboard)
+}
+
+// renderHours возвращает клавиатуру выбора часа для дня day.
+func (c *Calendar) renderHours(day time.Time) *InlineKeyboardMarkup {
+	keyboard := NewInlineKeyboard().Row(c.ignore(day.Format("02.01.2006"))).Width(6).Row()
+	for hour := 0; hour < 24; hour++ {
+		start := day.Add(time.Duration(hour) * time.Hour)
+		if c.rangeAllowed(start, start.Add(time.Hour)) {
+			keyboard.Add(InlineCallback(fmt.Sprintf("%02d", hour), c.data("h", start.Format("2006010215"))))
+		} else {
+			keyboard.Add(c.ignore("·"))
+		}
+	}
+	keyboard.Row(InlineCallback(c.Locale.Back, c.data("m", day.Format("200601"))))
+	return c.build(keyboard)
+}
+
+// renderMinutes возвращает клавиатуру выбора минут для часа hour.
+func (c *Calendar) renderMinutes(hour time.Time) *InlineKeyboardMarkup {
+	step := c.MinuteStep
+	if step <= 0 || step > 60 {
+		step = 15
+	}
+
+	keyboard := NewInlineKeyboard().Row(c.ignore(hour.Format("02.01.2006 15:__"))).Width(4).Row()
+	for minute := 0; minute < 60; minute += step {
+		at := hour.Add(time.Duration(minute) * time.Minute)
+		if c.rangeAllowed(at, at.Add(time.Minute)) {
+			keyboard.Add(InlineCallback(at.Format("15:04"), c.data("t", at.Format("200601021504"))))
+		} else {
+			keyboard.Add(c.ignore("·"))
+		}
+	}
+	keyboard.Row(InlineCallback(c.Locale.Back, c.data("d", hour.Format("20060102"))))
+	return c.build(keyboard)
+}
+
+func (c *Calendar) dayAllowed(day time.Time) bool {
+	return c.rangeAllowed(day, day.AddDate(0, 0, 1))
+}
+
+// rangeAllowed сообщает, пересекается ли интервал [from, to) с [Min, Max].
+func (c *Calendar) rangeAllowed(from, to time.Time) bool {
+	if !c.Min.IsZero() && !to.After(c.Min) {
+		return false
+	}
+	if !c.Max.IsZero() && from.After(c.Max) {
+		return false
+	}
+	return true
+}
+
+func (c *Calendar) build(keyboard *InlineKeyboardBuilder) *InlineKeyboardMarkup {
+	markup, err := keyboard.Build()
+	if err != nil {
+		log.Println("Error building calendar keyboard:", err)
+	}
+	return markup
+}
+
+func (c *Calendar) ignore(text string) InlineKeyboardButton {
+	return InlineCallback(text, c.data("x", ""))
+}
+
+func (c *Calendar) data(action, payload string) string {
+	return calendarPrefix + c.id + ":" + action + payload
+}
+
+func (c *Calendar) isCalendar(update Update) bool {
+	return update.CallbackQuery != nil && update.CallbackQuery.Message != nil &&
+		strings.HasPrefix(update.CallbackQuery.Data, calendarPrefix+c.id+":")
+}
+
+func (c *Calendar) handle(update Update) {
+	query := update.CallbackQuery
+	defer c.bot.AnswerCallbackQuery(query.ID, "", "false")
+
+	data := strings.TrimPrefix(query.Data, calendarPrefix+c.id+":")
+	if data == "" {
+		return
+	}
+	action, payload := data[:1], data[1:]
+	chatID := query.Message.Chat.ID
+	messageID := query.Message.MessageID
+
+	switch action {
+	case "m":
+		month, err := time.ParseInLocation("200601", payload, c.Location)
+		if err != nil {
+			return
+		}
+		c.bot.EditMessage(chatID, messageID, query.Message.Text, Utils{Inline: c.Render(month)})
+	case "d":
+		day, err := time.ParseInLocation("20060102", payload, c.Location)
+		if err != nil || !c.dayAllowed(day) {
+			return
+		}
+		if !c.WithTime {
+			c.OnSelect(update, day)
+			return
+		}
+		c.bot.EditMessage(chatID, messageID, query.Message.Text, Utils{Inline: c.renderHours(day)})
+	case "h":
+		hour, err := time.ParseInLocation("2006010215", payload, c.Location)
+		if err != nil {
+			return
+		}
+		c.bot.EditMessage(chatID, messageID, query.Message.Text, Utils{Inline: c.renderMinutes(hour)})
+	case "t":
+		at, err := time.ParseInLocation("200601021504", payload, c.Location)
+		if err != nil || !c.rangeAllowed(at, at.Add(time.Minute)) {
+			return
+		}
+		c.OnSelect(update, at)
+	}
+}
@@ -0,0 +1,39 @@
+package LCB
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalendarRowsStartAfterHeader(t *testing.T) {
+	c := NewBot("", false).NewCalendar("booking", nil)
+	c.Location = time.UTC
+	day := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+
+	checks := []struct {
+		name   string
+		markup *InlineKeyboardMarkup
+		rows   []int
+	}{
+		// Заголовок, 4 строки по 6 часов, "Назад".
+		{"hours", c.renderHours(day), []int{1, 6, 6, 6, 6, 1}},
+		// Заголовок, 4 интервала по 15 минут, "Назад".
+		{"minutes", c.renderMinutes(day.Add(10 * time.Hour)), []int{1, 4, 1}},
+	}
+	for _, check := range checks {
+		rows := check.markup.InlineKeyboard
+		if len(rows) != len(check.rows) {
+			t.Fatalf("%s: got %d rows, want %d", check.name, len(rows), len(check.rows))
+		}
+		for i, want := range check.rows {
+			if len(rows[i]) != want {
+				t.Errorf("%s: row %d has %d buttons, want %d", check.name, i, len(rows[i]), want)
+			}
+		}
+	}
+
+	month := c.Render(day).InlineKeyboard
+	if len(month[0]) != 3 || len(month[1]) != 7 || len(month[2]) != 7 {
+		t.Fatalf("month header rows = %d, %d, %d buttons", len(month[0]), len(month[1]), len(month[2]))
+	}
+}
@@ -0,0 +1,455 @@
+package LCB
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	codecInline = '.'
+	codecStored = '~'
+
+	codecMACSize   = 8
+	codecTokenSize = 9
+)
+
+var (
+	// ErrCallbackSignature возвращается, если подпись callback_data не совпала.
+	ErrCallbackSignature = errors.New("callback data signature mismatch")
+	// ErrCallbackExpired возвращается, если данные по токену не найдены или устарели.
+	ErrCallbackExpired = errors.New("callback data expired")
+	// ErrCallbackFormat возвращается для callback_data, созданных не этим кодеком.
+	ErrCallbackFormat = errors.New("malformed callback data")
+)
+
+// CallbackStore хранит на сервере данные, не помещающиеся в callback_data.
+type CallbackStore interface {
+	Put(token string, data []byte, ttl time.Duration) error
+	Get(token string) ([]byte, error)
+}
+
+// Пример использования
+// type orderAction struct {
+// 	OrderID int64
+// 	Action  string
+// }
+// codec := NewCallbackCodec("o").Sign(secret).Store(NewMemoryCallbackStore(), 24*time.Hour)
+// button, err := codec.Button("Отменить", orderAction{OrderID: 42, Action: "cancel"})
+// HandleCallbackData(bot, codec, func(c *Ctx, data orderAction) error {
+// 	return cancelOrder(data.OrderID)
+// })
+
+// CallbackCodec упаковывает значения в callback_data: компактно в двоичном виде
+// с base64, при необходимости с HMAC-подписью. Если результат длиннее 64 байт и
+// задано хранилище, в кнопку попадает только короткий токен.
+type CallbackCodec struct {
+	prefix string
+	key    []byte
+	store  CallbackStore
+	ttl    time.Duration
+}
+
+// NewCallbackCodec создаёт кодек. prefix отличает его данные от других кнопок бота.
+func NewCallbackCodec(prefix string) *CallbackCodec {
+	return &CallbackCodec{prefix: prefix}
+}
+
+// Sign включает HMAC-подпись данных ключом key, чтобы их нельзя было подделать.
+func (c *CallbackCodec) Sign(key []byte) *CallbackCodec {
+	c.key = key
+	return c
+}
+
+// Store задаёт хранилище для данных длиннее 64 байт и срок их хранения.
+func (c *CallbackCodec) Store(store CallbackStore, ttl time.Duration) *CallbackCodec {
+	c.store = store
+	c.ttl = ttl
+	return c
+}
+
+// Encode упаковывает v в строку для InlineKeyboardButton.CallbackData.
+func (c *CallbackCodec) Encode(v any) (string, error) {
+	payload, err := marshalCompact(v)
+	if err != nil {
+		return "", err
+	}
+
+	data := c.prefix + string(codecInline) + base64.RawURLEncoding.EncodeToString(c.seal(payload))
+	if len(data) <= MaxCallbackDataLen {
+		return data, nil
+	}
+	if c.store == nil {
+		return "", fmt.Errorf("%w: %d bytes and no store configured", ErrCallbackDataTooLong, len(data))
+	}
+
+	token := make([]byte, codecTokenSize)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	encodedToken := base64.RawURLEncoding.EncodeToString(token)
+	if err := c.store.Put(c.prefix+encodedToken, payload, c.ttl); err != nil {
+		return "", err
+	}
+	return c.prefix + string(codecStored) + encodedToken, nil
+}
+
+// Decode распаковывает callback_data, созданные Encode, в v (указатель).
+func (c *CallbackCodec) Decode(data string, v any) error {
+	rest, ok := strings.CutPrefix(data, c.prefix)
+	if !ok || rest == "" {
+		return ErrCallbackFormat
+	}
+
+	var payload []byte
+	switch rest[0] {
+	case codecInline:
+		raw, err := base64.RawURLEncoding.DecodeString(rest[1:])
+		if err != nil {
+			return ErrCallbackFormat
+		}
+		payload, err = c.open(raw)
+		if err != nil {
+			return err
+		}
+	case codecStored:
+		if c.store == nil {
+			return ErrCallbackExpired
+		}
+		var err error
+		payload, err = c.store.Get(c.prefix + rest[1:])
+		if err != nil {
+			return err
+		}
+	default:
+		return ErrCallbackFormat
+	}
+
+	return unmarshalCompact(payload, v)
+}
+
+// Match сообщает, относится ли нажатие кнопки к этому кодеку.
+func (c *CallbackCodec) Match(update Update) bool {
+	if update.CallbackQuery == nil {
+		return false
+	}
+	rest, ok := strings.CutPrefix(update.CallbackQuery.Data, c.prefix)
+	return ok && rest != "" && (rest[0] == codecInline || rest[0] == codecStored)
+}
+
+// Button создаёт кнопку с упакованным значением v.
+func (c *CallbackCodec) Button(text string, v any) (InlineKeyboardButton, error) {
+	data, err := c.Encode(v)
+	if err != nil {
+		return InlineKeyboardButton{}, err
+	}
+	return InlineCallback(text, data), nil
+}
+
+func (c *CallbackCodec) seal(payload []byte) []byte {
+	if c.key == nil {
+		return payload
+	}
+	return append(payload, c.mac(payload)...)
+}
+
+func (c *CallbackCodec) open(raw []byte) ([]byte, error) {
+	if c.key == nil {
+		return raw, nil
+	}
+	if len(raw) < codecMACSize {
+		return nil, ErrCallbackSignature
+	}
+	payload, sum := raw[:len(raw)-codecMACSize], raw[len(raw)-codecMACSize:]
+	if !hmac.Equal(sum, c.mac(payload)) {
+		return nil, ErrCallbackSignature
+	}
+	return payload, nil
+}
+
+func (c *CallbackCodec) mac(payload []byte) []byte {
+	h := hmac.New(sha256.New, c.key)
+	h.Write([]byte(c.prefix))
+	h.Write(payload)
+	return h.Sum(nil)[:codecMACSize]
+}
+
+// HandleCallbackData регистрирует обработчик нажатий кнопок, созданных codec,
+// и передаёт ему распакованное значение. Если ни обработчик, ни middleware
+// не вызвали c.Answer, на нажатие отвечается автоматически.
+func HandleCallbackData[T any](b *Bot, codec *CallbackCodec, handler func(c *Ctx, data T) error, middlewares ...Middleware) {
+	b.handleCallback(codec.Match, func(c *Ctx) error {
+		var data T
+		if err := codec.Decode(c.Update.CallbackQuery.Data, &data); err != nil {
+			return fmt.Errorf("decode callback data: %w", err)
+		}
+		return handler(c, data)
+	}, middlewares...)
+}
+
+// MemoryCallbackStore хранит данные кнопок в памяти процесса.
+type MemoryCallbackStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryCallbackEntry
+	puts    int
+}
+
+type memoryCallbackEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+func NewMemoryCallbackStore() *MemoryCallbackStore {
+	return &MemoryCallbackStore{entries: make(map[string]memoryCallbackEntry)}
+}
+
+func (s *MemoryCallbackStore) Put(token string, data []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+	s.entries[token] = memoryCallbackEntry{data: data, expires: expires}
+
+	// Время от времени удаляем устаревшие записи, чтобы хранилище не росло бесконечно.
+	s.puts++
+	if s.puts%1024 == 0 {
+		now := time.Now()
+		for key, entry := range s.entries {
+			if !entry.expires.IsZero() && now.After(entry.expires) {
+				delete(s.entries, key)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *MemoryCallbackStore) Get(token string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[token]
+	if !ok {
+		return nil, ErrCallbackExpired
+	}
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		delete(s.entries, token)
+		return nil, ErrCallbackExpired
+	}
+	return entry.data, nil
+}
+
+// marshalCompact кодирует значение без имён полей и типов: структуры - полями
+// по порядку объявления, числа - varint, строки и срезы - с длиной в начале.
+func marshalCompact(v any) ([]byte, error) {
+	if v == nil {
+		return nil, errors.New("callback codec: cannot encode nil")
+	}
+	return appendCompact(nil, reflect.ValueOf(v))
+}
+
+func appendCompact(buf []byte, v reflect.Value) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return append(buf, 0), nil
+		}
+		return appendCompact(append(buf, 1), v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			return append(buf, 1), nil
+		}
+		return append(buf, 0), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binary.AppendVarint(buf, v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return binary.AppendUvarint(buf, v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return binary.LittleEndian.AppendUint64(buf, math.Float64bits(v.Float())), nil
+	case reflect.String:
+		buf = binary.AppendUvarint(buf, uint64(v.Len()))
+		return append(buf, v.String()...), nil
+	case reflect.Slice:
+		buf = binary.AppendUvarint(buf, uint64(v.Len()))
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return append(buf, v.Bytes()...), nil
+		}
+		fallthrough
+	case reflect.Array:
+		var err error
+		for i := 0; i < v.Len(); i++ {
+			if buf, err = appendCompact(buf, v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case reflect.Struct:
+		var err error
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			if buf, err = appendCompact(buf, v.Field(i)); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	}
+	return nil, fmt.Errorf("callback codec: unsupported type %s", v.Type())
+}
+
+func unmarshalCompact(data []byte, v any) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("callback codec: expected non-nil pointer, got %T", v)
+	}
+	d := compactDecoder{data: data}
+	if err := d.decode(target.Elem()); err != nil {
+		return err
+	}
+	if len(d.data) != 0 {
+		return ErrCallbackFormat
+	}
+	return nil
+}
+
+type compactDecoder struct {
+	data []byte
+}
+
+func (d *compactDecoder) uvarint() (uint64, error) {
+	value, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		return 0, ErrCallbackFormat
+	}
+	d.data = d.data[n:]
+	return value, nil
+}
+
+func (d *compactDecoder) bytes(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)) {
+		return nil, ErrCallbackFormat
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b, nil
+}
+
+func (d *compactDecoder) decode(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		flag, err := d.bytes(1)
+		if err != nil {
+			return err
+		}
+		if flag[0] == 0 {
+			v.SetZero()
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decode(v.Elem())
+	case reflect.Bool:
+		flag, err := d.bytes(1)
+		if err != nil {
+			return err
+		}
+		v.SetBool(flag[0] != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, n := binary.Varint(d.data)
+		if n <= 0 {
+			return ErrCallbackFormat
+		}
+		d.data = d.data[n:]
+		// Данные приходят от клиента: значение, не помещающееся в поле, считаем подделкой.
+		if v.OverflowInt(value) {
+			return ErrCallbackFormat
+		}
+		v.SetInt(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value, err := d.uvarint()
+		if err != nil {
+			return err
+		}
+		if v.OverflowUint(value) {
+			return ErrCallbackFormat
+		}
+		v.SetUint(value)
+	case reflect.Float32, reflect.Float64:
+		raw, err := d.bytes(8)
+		if err != nil {
+			return err
+		}
+		value := math.Float64frombits(binary.LittleEndian.Uint64(raw))
+		if v.OverflowFloat(value) {
+			return ErrCallbackFormat
+		}
+		v.SetFloat(value)
+	case reflect.String:
+		n, err := d.uvarint()
+		if err != nil {
+			return err
+		}
+		raw, err := d.bytes(n)
+		if err != nil {
+			return err
+		}
+		v.SetString(string(raw))
+	case reflect.Slice:
+		n, err := d.uvarint()
+		if err != nil {
+			return err
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			raw, err := d.bytes(n)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(append([]byte(nil), raw...))
+			return nil
+		}
+		if n == 0 {
+			v.SetZero()
+			return nil
+		}
+		if n > uint64(len(d.data)) {
+			return ErrCallbackFormat
+		}
+		v.Set(reflect.MakeSlice(v.Type(), int(n), int(n)))
+		for i := 0; i < int(n); i++ {
+			if err := d.decode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := d.decode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			if err := d.decode(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("callback codec: unsupported type %s", v.Type())
+	}
+	return nil
+}
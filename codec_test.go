@@ -0,0 +1,42 @@
+package LCB
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCallbackCodecEncodeNil(t *testing.T) {
+	if _, err := NewCallbackCodec("x").Encode(nil); err == nil {
+		t.Fatal("Encode(nil) returned no error")
+	}
+}
+
+func TestCallbackCodecRejectsOverflow(t *testing.T) {
+	codec := NewCallbackCodec("x")
+
+	cases := []struct {
+		name    string
+		encoded any
+		decoded any
+	}{
+		{"int8", int64(1000), new(int8)},
+		{"uint8", uint64(300), new(uint8)},
+		{"uint16 in struct", struct{ ID uint64 }{ID: 1 << 20}, new(struct{ ID uint16 })},
+		{"float32", float64(1e300), new(float32)},
+	}
+	for _, tc := range cases {
+		data, err := codec.Encode(tc.encoded)
+		if err != nil {
+			t.Fatalf("%s: Encode: %v", tc.name, err)
+		}
+		if err := codec.Decode(data, tc.decoded); !errors.Is(err, ErrCallbackFormat) {
+			t.Errorf("%s: Decode = %v, want ErrCallbackFormat", tc.name, err)
+		}
+	}
+
+	data, _ := codec.Encode(int64(-100))
+	var small int8
+	if err := codec.Decode(data, &small); err != nil || small != -100 {
+		t.Fatalf("Decode in range = %d, %v", small, err)
+	}
+}
@@ -0,0 +1,220 @@
+package LCB
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// ErrMissingArgument возвращается из Command.Bind, если обязательного аргумента нет.
+var ErrMissingArgument = errors.New("missing command argument")
+
+// Пример использования
+// type banArgs struct {
+// 	UserID int64
+// 	Reason string `arg:"rest,optional"`
+// }
+// bot.Command("ban", func(update Update, cmd Command) {
+// 	var args banArgs
+// 	if err := cmd.Bind(&args); err != nil {
+// 		bot.SendMessage(update.Message.Chat.ID, "Использование: /ban <id> [причина]", Utils{})
+// 		return
+// 	}
+// })
+
+// Command представляет собой разобранную команду бота вида "/name@bot arg1 "arg 2"".
+type Command struct {
+	Name    string   // Имя команды без "/" и "@bot"
+	Mention string   // Имя бота после "@", если было указано
+	Args    []string // Аргументы с учётом кавычек
+	RawArgs string   // Текст после команды как есть
+}
+
+// Command регистрирует обработчик команды name (без "/").
+// В группах команда вида "/name@other_bot" игнорируется.
+func (b *Bot) Command(name string, callback func(update Update, cmd Command)) {
+	b.AddHandler(func(update Update) bool {
+		cmd, ok := ParseCommand(update.Message)
+		return ok && b.MatchesCommand(cmd, name)
+	}, func(update Update) {
+		cmd, _ := ParseCommand(update.Message)
+		callback(update, cmd)
+	})
+}
+
+// MatchesCommand сообщает, что cmd - команда name (без "/", без учёта регистра),
+// адресованная этому боту. Команды для другого бота ("/start@other_bot") не подходят.
+func (b *Bot) MatchesCommand(cmd Command, name string) bool {
+	if !strings.EqualFold(cmd.Name, name) {
+		return false
+	}
+	// Если имя бота неизвестно, принимаем команду с любым упоминанием.
+	return cmd.Mention == "" || b.Username == "" || strings.EqualFold(cmd.Mention, b.Username)
+}
+
+// ParseCommand разбирает команду в начале сообщения по сущности bot_command.
+func ParseCommand(message *Message) (Command, bool) {
+	if message == nil {
+		return Command{}, false
+	}
+
+	for _, entity := range message.Entities {
+		if entity.Type != "bot_command" || entity.Offset != 0 {
+			continue
+		}
+
+		text := utf16.Encode([]rune(message.Text))
+		if int(entity.Length) > len(text) || entity.Length < 2 {
+			return Command{}, false
+		}
+		command := string(utf16.Decode(text[1:entity.Length]))
+		raw := strings.TrimSpace(string(utf16.Decode(text[entity.Length:])))
+
+		cmd := Command{RawArgs: raw, Args: SplitArgs(raw)}
+		cmd.Name, cmd.Mention, _ = strings.Cut(command, "@")
+		return cmd, true
+	}
+
+	return Command{}, false
+}
+
+// SplitArgs делит строку на аргументы по пробелам.
+// Текст в двойных или одинарных кавычках считается одним аргументом, "\" экранирует следующий символ.
+func SplitArgs(s string) []string {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg, escaped := false, false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inArg = r, true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args
+}
+
+// Bind записывает аргументы команды в поля структуры dst по порядку их объявления.
+// Тег `arg:"-"` пропускает поле, `arg:"optional"` делает аргумент необязательным,
+// `arg:"rest"` забирает все оставшиеся аргументы (в string - через пробел, в []string - списком).
+// Поддерживаются string, bool, целые и вещественные числа.
+func (c Command) Bind(dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind: expected pointer to struct, got %T", dst)
+	}
+	v = v.Elem()
+	t := v.Type()
+
+	next := 0
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("arg")
+		if tag == "-" {
+			continue
+		}
+		var optional, rest bool
+		for _, option := range strings.Split(tag, ",") {
+			switch strings.TrimSpace(option) {
+			case "optional":
+				optional = true
+			case "rest":
+				rest = true
+			}
+		}
+
+		if next >= len(c.Args) {
+			if !optional {
+				return fmt.Errorf("%w: %s", ErrMissingArgument, field.Name)
+			}
+			continue
+		}
+
+		if rest {
+			remaining := c.Args[next:]
+			next = len(c.Args)
+			if field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.String {
+				v.Field(i).Set(reflect.ValueOf(append([]string(nil), remaining...)))
+				continue
+			}
+			if err := setArg(v.Field(i), strings.Join(remaining, " ")); err != nil {
+				return fmt.Errorf("bind %s: %w", field.Name, err)
+			}
+			continue
+		}
+
+		if err := setArg(v.Field(i), c.Args[next]); err != nil {
+			return fmt.Errorf("bind %s: %w", field.Name, err)
+		}
+		next++
+	}
+
+	return nil
+}
+
+func setArg(field reflect.Value, arg string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(arg)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(arg)
+		if err != nil {
+			return err
+		}
+		field.SetBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := strconv.ParseInt(arg, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value, err := strconv.ParseUint(arg, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(value)
+	case reflect.Float32, reflect.Float64:
+		value, err := strconv.ParseFloat(arg, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(value)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package LCB
+
+import (
+	"context"
+	"sync"
+)
+
+// Пример использования
+// bot.Handle(isHello, func(c *Ctx) error {
+// 	c.SendTyping()
+// 	c.Reply("Привет, " + c.User.FirstName)
+// 	return nil
+// })
+
+// Ctx передаётся обработчикам и middleware одного обновления. Поля Chat, User
+// и Message уже извлечены из обновления и могут быть nil.
+type Ctx struct {
+	Bot     *Bot
+	Update  Update
+	Chat    *Chat
+	User    *User
+	Message *Message
+
+	mu       sync.Mutex
+	ctx      context.Context
+	values   map[string]any
+	answered bool
+	release  func()
+}
+
+func newCtx(b *Bot, update Update) *Ctx {
+	return &Ctx{
+		Bot:     b,
+		Update:  update,
+		Chat:    update.EffectiveChat(),
+		User:    update.EffectiveUser(),
+		Message: update.EffectiveMessage(),
+		ctx:     b.ctx,
+	}
+}
+
+// Context возвращает context.Context обработки. Он отменяется при остановке бота.
+func (c *Ctx) Context() context.Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ctx
+}
+
+// SetContext заменяет context.Context, например чтобы middleware добавило значение или дедлайн.
+func (c *Ctx) SetContext(ctx context.Context) {
+	c.mu.Lock()
+	c.ctx = ctx
+	c.mu.Unlock()
+}
+
+// Set сохраняет значение, доступное следующим middleware и обработчикам этого обновления.
+func (c *Ctx) Set(key string, value any) {
+	c.mu.Lock()
+	if c.values == nil {
+		c.values = make(map[string]any)
+	}
+	c.values[key] = value
+	c.mu.Unlock()
+}
+
+func (c *Ctx) Get(key string) (any, bool) {
+	c.mu.Lock()
+	value, ok := c.values[key]
+	c.mu.Unlock()
+	return value, ok
+}
+
+// ChatID возвращает ID чата обновления или 0.
+func (c *Ctx) ChatID() int64 {
+	if c.Chat == nil {
+		return 0
+	}
+	return c.Chat.ID
+}
+
+// Reply отправляет сообщение в чат обновления и возвращает его ID.
+func (c *Ctx) Reply(text string, utils ...Utils) int {
+	return c.Bot.SendMessage(c.ChatID(), text, firstUtils(utils))
+}
+
+// Edit редактирует сообщение обновления, например сообщение с нажатой кнопкой.
+func (c *Ctx) Edit(text string, utils ...Utils) int {
+	if c.Message == nil {
+		return 0
+	}
+	return c.Bot.EditMessage(c.ChatID(), c.Message.MessageID, text, firstUtils(utils))
+}
+
+// Answer отвечает на нажатие inline-кнопки. Без ответа кнопка у пользователя
+// продолжает показывать индикатор загрузки.
+func (c *Ctx) Answer(text string, showAlert bool) {
+	if c.Update.CallbackQuery == nil {
+		return
+	}
+	c.mu.Lock()
+	c.answered = true
+	c.mu.Unlock()
+
+	alert := "false"
+	if showAlert {
+		alert = "true"
+	}
+	c.Bot.AnswerCallbackQuery(c.Update.CallbackQuery.ID, text, alert)
+}
+
+// Delete удаляет сообщение обновления.
+func (c *Ctx) Delete() {
+	if c.Message == nil {
+		return
+	}
+	c.Bot.DeleteMessage(c.ChatID(), c.Message.MessageID)
+}
+
+// SendTyping показывает в чате статус "печатает...".
+func (c *Ctx) SendTyping() {
+	c.Bot.SendChatAction(c.ChatID(), "typing")
+}
+
+func firstUtils(utils []Utils) Utils {
+	if len(utils) == 0 {
+		return Utils{}
+	}
+	return utils[0]
+}
+
+// answerIfNeeded отвечает на нажатие кнопки, если обработчик не сделал этого сам.
+func (c *Ctx) answerIfNeeded() {
+	c.mu.Lock()
+	answered := c.answered
+	c.mu.Unlock()
+
+	if !answered {
+		c.Answer("", false)
+	}
+}
+
+// releaseWorker освобождает воркер пула перед долгим ожиданием, чтобы ответ
+// пользователя и другие обновления не ждали в очереди за этим обработчиком.
+func (c *Ctx) releaseWorker() {
+	c.mu.Lock()
+	release := c.release
+	c.release = nil
+	c.mu.Unlock()
+
+	if release != nil {
+		release()
+	}
+}
@@ -0,0 +1,129 @@
+package LCB
+
+import "sync/atomic"
+
+// DispatchMode определяет, как обновления распределяются между воркерами.
+type DispatchMode int
+
+const (
+	// DispatchParallel отдаёт обновление любому свободному воркеру.
+	DispatchParallel DispatchMode = iota
+	// DispatchPerChat обрабатывает обновления одного чата строго по порядку,
+	// а разные чаты - параллельно.
+	DispatchPerChat
+	// DispatchPerUser обрабатывает обновления одного пользователя строго по порядку,
+	// а разных пользователей - параллельно.
+	DispatchPerUser
+)
+
+// DispatcherConfig задаёт параметры обработки обновлений.
+type DispatcherConfig struct {
+	// Workers - число воркеров. 0 - отдельная горутина на каждое обновление без ограничений.
+	Workers int
+	// QueueSize - длина очереди каждого воркера. Когда очередь заполнена,
+	// получение новых обновлений приостанавливается.
+	// Обработчик, ждущий ответа через Wait или Ask, не занимает воркер: на время
+	// ожидания вместо него запускается новый. Поэтому пока обработчик ждёт,
+	// другие обновления того же чата могут обрабатываться параллельно с ним.
+	QueueSize int
+	Mode      DispatchMode
+}
+
+// Пример использования
+// bot := NewBot(token, false)
+// bot.SetDispatcher(DispatcherConfig{Workers: 16, QueueSize: 64, Mode: DispatchPerChat})
+// bot.Start()
+
+// SetDispatcher настраивает обработку обновлений. Вызывается до Start.
+func (b *Bot) SetDispatcher(config DispatcherConfig) {
+	b.dispatcherConfig = config
+}
+
+// dispatchJob - задача воркера. release освобождает воркер до завершения задачи.
+type dispatchJob func(release func())
+
+type dispatcher struct {
+	mode   DispatchMode
+	queues []chan dispatchJob
+}
+
+func newDispatcher(config DispatcherConfig) *dispatcher {
+	if config.Workers <= 0 {
+		return nil
+	}
+
+	d := &dispatcher{mode: config.Mode}
+	if config.Mode == DispatchParallel {
+		queue := make(chan dispatchJob, config.QueueSize)
+		for i := 0; i < config.Workers; i++ {
+			d.queues = append(d.queues, queue)
+		}
+		go d.workers(queue, config.Workers)
+		return d
+	}
+
+	for i := 0; i < config.Workers; i++ {
+		queue := make(chan dispatchJob, config.QueueSize)
+		d.queues = append(d.queues, queue)
+		go d.workers(queue, 1)
+	}
+	return d
+}
+
+func (d *dispatcher) workers(queue chan dispatchJob, count int) {
+	for i := 0; i < count; i++ {
+		go d.work(queue)
+	}
+}
+
+// work выполняет задачи из очереди. Если задача освободила воркер, вместо него
+// уже запущен новый, и эта горутина завершается вместе с задачей.
+func (d *dispatcher) work(queue chan dispatchJob) {
+	for job := range queue {
+		var released atomic.Bool
+		job(func() {
+			if released.CompareAndSwap(false, true) {
+				go d.work(queue)
+			}
+		})
+		if released.Load() {
+			return
+		}
+	}
+}
+
+// submit ставит задачу в очередь воркера, блокируясь, пока в очереди нет места.
+func (d *dispatcher) submit(update Update, job dispatchJob) {
+	d.queues[d.index(update)] <- job
+}
+
+func (d *dispatcher) index(update Update) int {
+	var key int64
+	switch d.mode {
+	case DispatchPerChat:
+		if chat := update.EffectiveChat(); chat != nil {
+			key = chat.ID
+		} else if user := update.EffectiveUser(); user != nil {
+			key = user.ID
+		}
+	case DispatchPerUser:
+		if user := update.EffectiveUser(); user != nil {
+			key = user.ID
+		} else if chat := update.EffectiveChat(); chat != nil {
+			key = chat.ID
+		}
+	default:
+		return 0
+	}
+	return int(uint64(key) % uint64(len(d.queues)))
+}
+
+func (d *dispatcher) close() {
+	if d.mode == DispatchParallel {
+		close(d.queues[0])
+		return
+	}
+	for _, queue := range d.queues {
+		close(queue)
+	}
+}
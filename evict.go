@@ -0,0 +1,248 @@
+package LCB
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// EvictReason - причина, по которой элемент удалён из NewState автоматически.
+type EvictReason int
+
+const (
+	// EvictExpired - истёк TTL элемента.
+	EvictExpired EvictReason = iota
+	// EvictCapacity - элемент дольше всех не использовался, а состояние превысило MaxSize.
+	EvictCapacity
+)
+
+// Пример использования
+// captchas := NewNewState[int64, Captcha]()
+// captchas.SetTTL(2 * time.Minute)
+// captchas.OnEvict(func(userID int64, captcha Captcha, reason EvictReason) {
+// 	b.EditMessage(captcha.ChatID, captcha.MessageID, "Время вышло", Utils{})
+// })
+// captchas.StartJanitor(10 * time.Second)
+// defer captchas.Close()
+
+// evictor хранит TTL и порядок использования элементов NewState.
+type evictor[K comparable, V any] struct {
+	ttl     time.Duration
+	expires map[K]time.Time
+
+	maxSize int
+	// lruMu защищает lru при чтении под RLock: GetElement перемещает элемент в начало списка.
+	lruMu    sync.Mutex
+	lru      *list.List
+	lruItems map[K]*list.Element
+
+	onEvict func(key K, element V, reason EvictReason)
+	evicted []evictedEntry[K, V]
+
+	stopJanitor chan struct{}
+	janitorDone chan struct{}
+}
+
+type evictedEntry[K comparable, V any] struct {
+	key     K
+	element V
+	reason  EvictReason
+}
+
+// SetTTL задаёт время жизни для элементов, записанных после вызова. 0 - без ограничения.
+func (ns *NewState[K, V]) SetTTL(ttl time.Duration) {
+	ns.Mu.Lock()
+	ns.ev.ttl = ttl
+	ns.Mu.Unlock()
+}
+
+// SetMaxSize ограничивает число элементов: при превышении удаляются те,
+// к которым дольше всего не обращались. 0 - без ограничения.
+func (ns *NewState[K, V]) SetMaxSize(size int) {
+	ns.Mu.Lock()
+	defer ns.unlockEvicted()
+
+	ns.ev.maxSize = size
+	if size <= 0 {
+		ns.ev.lru, ns.ev.lruItems = nil, nil
+		return
+	}
+	if ns.ev.lru == nil {
+		ns.ev.lru = list.New()
+		ns.ev.lruItems = make(map[K]*list.Element, len(ns.State))
+		for key := range ns.State {
+			ns.ev.lruItems[key] = ns.ev.lru.PushFront(key)
+		}
+	}
+	ns.evictOverflow()
+}
+
+// OnEvict задаёт функцию, вызываемую для элементов, удалённых по TTL или из-за MaxSize.
+// Вызывается без блокировки состояния. DeleteElement её не вызывает.
+func (ns *NewState[K, V]) OnEvict(callback func(key K, element V, reason EvictReason)) {
+	ns.Mu.Lock()
+	ns.ev.onEvict = callback
+	ns.Mu.Unlock()
+}
+
+// PutWithTTL сохраняет элемент с собственным временем жизни вместо SetTTL.
+func (ns *NewState[K, V]) PutWithTTL(key K, element V, ttl time.Duration) error {
+	ns.Mu.Lock()
+	defer ns.unlockEvicted()
+
+	if err := ns.put(key, element); err != nil {
+		return err
+	}
+	ns.expireAfter(key, ttl)
+	return nil
+}
+
+// Expire задаёт новое время жизни существующему элементу. 0 снимает ограничение.
+func (ns *NewState[K, V]) Expire(key K, ttl time.Duration) bool {
+	ns.Mu.Lock()
+	defer ns.Mu.Unlock()
+
+	if _, ok := ns.State[key]; !ok || ns.expired(key, time.Now()) {
+		return false
+	}
+	ns.expireAfter(key, ttl)
+	return true
+}
+
+// StartJanitor запускает фоновое удаление просроченных элементов раз в interval.
+// Повторный вызов перезапускает janitor с новым интервалом.
+func (ns *NewState[K, V]) StartJanitor(interval time.Duration) {
+	ns.StopJanitor()
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	ns.Mu.Lock()
+	ns.ev.stopJanitor, ns.ev.janitorDone = stop, done
+	ns.Mu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ns.RemoveExpired()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// StopJanitor останавливает janitor и ждёт его завершения.
+func (ns *NewState[K, V]) StopJanitor() {
+	ns.Mu.Lock()
+	stop, done := ns.ev.stopJanitor, ns.ev.janitorDone
+	ns.ev.stopJanitor, ns.ev.janitorDone = nil, nil
+	ns.Mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// RemoveExpired удаляет все просроченные элементы и возвращает их число.
+func (ns *NewState[K, V]) RemoveExpired() int {
+	ns.Mu.Lock()
+	defer ns.unlockEvicted()
+
+	now := time.Now()
+	removed := 0
+	for key := range ns.ev.expires {
+		if ns.expired(key, now) {
+			ns.evict(key, EvictExpired)
+			removed++
+		}
+	}
+	return removed
+}
+
+// expired сообщает, что у key истёк TTL. Вызывается под ns.Mu (достаточно RLock).
+func (ns *NewState[K, V]) expired(key K, now time.Time) bool {
+	deadline, ok := ns.ev.expires[key]
+	return ok && !now.Before(deadline)
+}
+
+// tracked обновляет TTL и место в LRU после записи key. Вызывается под ns.Mu.
+func (ns *NewState[K, V]) tracked(key K) {
+	ns.expireAfter(key, ns.ev.ttl)
+	if ns.ev.lru == nil {
+		return
+	}
+	if item, ok := ns.ev.lruItems[key]; ok {
+		ns.ev.lru.MoveToFront(item)
+	} else {
+		ns.ev.lruItems[key] = ns.ev.lru.PushFront(key)
+	}
+	ns.evictOverflow()
+}
+
+// touch отмечает чтение key для LRU. Вызывается под ns.Mu.RLock.
+func (ns *NewState[K, V]) touch(key K) {
+	if ns.ev.lru == nil {
+		return
+	}
+	ns.ev.lruMu.Lock()
+	if item, ok := ns.ev.lruItems[key]; ok {
+		ns.ev.lru.MoveToFront(item)
+	}
+	ns.ev.lruMu.Unlock()
+}
+
+// untrack забывает TTL и место в LRU удалённого key. Вызывается под ns.Mu.
+func (ns *NewState[K, V]) untrack(key K) {
+	delete(ns.ev.expires, key)
+	if item, ok := ns.ev.lruItems[key]; ok {
+		ns.ev.lru.Remove(item)
+		delete(ns.ev.lruItems, key)
+	}
+}
+
+func (ns *NewState[K, V]) expireAfter(key K, ttl time.Duration) {
+	if ttl <= 0 {
+		delete(ns.ev.expires, key)
+		return
+	}
+	if ns.ev.expires == nil {
+		ns.ev.expires = make(map[K]time.Time)
+	}
+	ns.ev.expires[key] = time.Now().Add(ttl)
+}
+
+func (ns *NewState[K, V]) evictOverflow() {
+	for ns.ev.maxSize > 0 && len(ns.State) > ns.ev.maxSize {
+		oldest := ns.ev.lru.Back()
+		if oldest == nil {
+			return
+		}
+		ns.evict(oldest.Value.(K), EvictCapacity)
+	}
+}
+
+func (ns *NewState[K, V]) evict(key K, reason EvictReason) {
+	element, ok := ns.State[key]
+	if !ok {
+		return
+	}
+	ns.remove(key)
+	if ns.ev.onEvict != nil {
+		ns.ev.evicted = append(ns.ev.evicted, evictedEntry[K, V]{key: key, element: element, reason: reason})
+	}
+}
+
+// unlockEvicted снимает ns.Mu и вызывает OnEvict для удалённых под блокировкой элементов.
+func (ns *NewState[K, V]) unlockEvicted() {
+	evicted, callback := ns.ev.evicted, ns.ev.onEvict
+	ns.ev.evicted = nil
+	ns.Mu.Unlock()
+
+	for _, entry := range evicted {
+		callback(entry.key, entry.element, entry.reason)
+	}
+}
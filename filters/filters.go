@@ -0,0 +1,208 @@
+// Package filters содержит готовые фильтры для Bot.AddHandler и Bot.Handle.
+//
+// Пример использования
+//
+//	bot.AddHandler(filters.And(filters.Private, filters.Photo), onPhoto)
+//	bot.AddHandler(filters.Or(filters.Command(bot, "start"), filters.Text("Начать")), onStart)
+package filters
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Aloero/LCB"
+)
+
+// Filter решает, подходит ли обновление обработчику.
+type Filter func(update LCB.Update) bool
+
+// And подходит, если подходят все фильтры.
+func And(filters ...Filter) Filter {
+	return func(update LCB.Update) bool {
+		for _, filter := range filters {
+			if !filter(update) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or подходит, если подходит хотя бы один фильтр.
+func Or(filters ...Filter) Filter {
+	return func(update LCB.Update) bool {
+		for _, filter := range filters {
+			if filter(update) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func Not(filter Filter) Filter {
+	return func(update LCB.Update) bool {
+		return !filter(update)
+	}
+}
+
+// Any подходит для любого обновления.
+var Any Filter = func(update LCB.Update) bool {
+	return true
+}
+
+// message возвращает новое или отредактированное сообщение либо пост канала.
+// Сообщение с нажатой inline-кнопкой сюда не относится.
+func message(update LCB.Update) *LCB.Message {
+	switch {
+	case update.Message != nil:
+		return update.Message
+	case update.EditedMessage != nil:
+		return update.EditedMessage
+	case update.ChannelPost != nil:
+		return update.ChannelPost
+	case update.EditedChannelPost != nil:
+		return update.EditedChannelPost
+	}
+	return nil
+}
+
+// messageFilter подходит, если в обновлении есть сообщение и оно удовлетворяет check.
+func messageFilter(check func(message *LCB.Message) bool) Filter {
+	return func(update LCB.Update) bool {
+		message := message(update)
+		return message != nil && check(message)
+	}
+}
+
+// Text подходит для сообщений с точно таким текстом.
+func Text(text string) Filter {
+	return messageFilter(func(message *LCB.Message) bool {
+		return message.Text == text
+	})
+}
+
+// TextPrefix подходит для сообщений, текст которых начинается с prefix.
+func TextPrefix(prefix string) Filter {
+	return messageFilter(func(message *LCB.Message) bool {
+		return strings.HasPrefix(message.Text, prefix)
+	})
+}
+
+// TextRegex подходит для сообщений, текст которых соответствует pattern.
+// Паникует, если pattern не компилируется, как regexp.MustCompile.
+func TextRegex(pattern string) Filter {
+	re := regexp.MustCompile(pattern)
+	return messageFilter(func(message *LCB.Message) bool {
+		return re.MatchString(message.Text)
+	})
+}
+
+// Command подходит для команд с одним из имён names (без "/").
+// Команды, адресованные другому боту ("/start@other_bot"), не подходят.
+func Command(b *LCB.Bot, names ...string) Filter {
+	return messageFilter(func(message *LCB.Message) bool {
+		cmd, ok := LCB.ParseCommand(message)
+		if !ok {
+			return false
+		}
+		return slices.ContainsFunc(names, func(name string) bool {
+			return b.MatchesCommand(cmd, name)
+		})
+	})
+}
+
+// callbackFilter подходит для нажатий inline-кнопок, data которых удовлетворяет check.
+func callbackFilter(check func(data string) bool) Filter {
+	return func(update LCB.Update) bool {
+		return update.CallbackQuery != nil && check(update.CallbackQuery.Data)
+	}
+}
+
+// CallbackData подходит для нажатий кнопок с точно такими данными.
+func CallbackData(data string) Filter {
+	return callbackFilter(func(d string) bool {
+		return d == data
+	})
+}
+
+// CallbackPrefix подходит для нажатий кнопок, данные которых начинаются с prefix.
+func CallbackPrefix(prefix string) Filter {
+	return callbackFilter(func(data string) bool {
+		return strings.HasPrefix(data, prefix)
+	})
+}
+
+// CallbackRegex подходит для нажатий кнопок, данные которых соответствуют pattern.
+// Паникует, если pattern не компилируется, как regexp.MustCompile.
+func CallbackRegex(pattern string) Filter {
+	re := regexp.MustCompile(pattern)
+	return callbackFilter(re.MatchString)
+}
+
+// ChatType подходит для обновлений из чатов указанных типов:
+// "private", "group", "supergroup", "channel".
+func ChatType(types ...string) Filter {
+	return func(update LCB.Update) bool {
+		chat := update.EffectiveChat()
+		return chat != nil && slices.Contains(types, chat.Type)
+	}
+}
+
+var (
+	Private    = ChatType("private")
+	Group      = ChatType("group", "supergroup")
+	Supergroup = ChatType("supergroup")
+	Channel    = ChatType("channel")
+)
+
+// FromUsers подходит для обновлений от пользователей с указанными ID.
+func FromUsers(ids ...int64) Filter {
+	return func(update LCB.Update) bool {
+		user := update.EffectiveUser()
+		return user != nil && slices.Contains(ids, user.ID)
+	}
+}
+
+// ReplyToBot подходит для сообщений, отвечающих на сообщение бота b.
+func ReplyToBot(b *LCB.Bot) Filter {
+	return messageFilter(func(message *LCB.Message) bool {
+		reply := message.ReplyTo
+		return reply != nil && reply.From != nil && reply.From.IsBot &&
+			(b.Username == "" || strings.EqualFold(reply.From.Username, b.Username))
+	})
+}
+
+// Forwarded подходит для пересланных сообщений.
+var Forwarded = messageFilter(func(message *LCB.Message) bool {
+	return message.ForwardFrom != nil || message.ForwardFromChat != nil || message.ForwardDate != 0
+})
+
+// Edited подходит для отредактированных сообщений и постов.
+var Edited Filter = func(update LCB.Update) bool {
+	return update.EditedMessage != nil || update.EditedChannelPost != nil
+}
+
+// Callback подходит для любых нажатий inline-кнопок.
+var Callback Filter = func(update LCB.Update) bool {
+	return update.CallbackQuery != nil
+}
+
+// Типы содержимого сообщения.
+var (
+	HasText   = messageFilter(func(m *LCB.Message) bool { return m.Text != "" })
+	Photo     = messageFilter(func(m *LCB.Message) bool { return len(m.Photo) > 0 })
+	Document  = messageFilter(func(m *LCB.Message) bool { return m.Document != nil })
+	Audio     = messageFilter(func(m *LCB.Message) bool { return m.Audio != nil })
+	Voice     = messageFilter(func(m *LCB.Message) bool { return m.Voice != nil })
+	Video     = messageFilter(func(m *LCB.Message) bool { return m.Video != nil })
+	VideoNote = messageFilter(func(m *LCB.Message) bool { return m.VideoNote != nil })
+	Animation = messageFilter(func(m *LCB.Message) bool { return m.Animation != nil })
+	Sticker   = messageFilter(func(m *LCB.Message) bool { return m.Sticker != nil })
+	Dice      = messageFilter(func(m *LCB.Message) bool { return m.Dice != nil })
+	Location  = messageFilter(func(m *LCB.Message) bool { return m.Location != nil })
+	Venue     = messageFilter(func(m *LCB.Message) bool { return m.Venue != nil })
+	Contact   = messageFilter(func(m *LCB.Message) bool { return m.Contact != nil })
+	Game      = messageFilter(func(m *LCB.Message) bool { return m.Game != nil })
+)
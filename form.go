@@ -0,0 +1,390 @@
+package LCB
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ErrFormCancelled возвращается из Form.Run, если пользователь отменил заполнение.
+var ErrFormCancelled = errors.New("form cancelled")
+
+// FieldType определяет, как разбирается ответ на поле формы.
+type FieldType int
+
+const (
+	// FieldText - произвольный текст, значение string.
+	FieldText FieldType = iota
+	// FieldInt - целое число, значение int64.
+	FieldInt
+	// FieldEmail - адрес электронной почты, значение string.
+	FieldEmail
+	// FieldPhone - телефон по кнопке "Отправить контакт" или текстом, значение string.
+	FieldPhone
+	// FieldDate - дата в формате Form.DateLayout, значение time.Time.
+	FieldDate
+	// FieldPhoto - фотография, значение string с file_id самого большого размера.
+	FieldPhoto
+)
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 ()-]{5,18}[0-9]$`)
+
+// FormField описывает одно поле формы.
+type FormField struct {
+	// Name - имя поля структуры или значение её тега `form:"..."`.
+	Name string
+	// Label - подпись поля в итоговой сводке, по умолчанию Name.
+	Label  string
+	Prompt string
+	Type   FieldType
+	// Optional разрешает пропустить поле кнопкой Form.Skip.
+	Optional bool
+	// Validate дополнительно проверяет разобранное значение. Текст ошибки отправляется пользователю.
+	Validate func(value any) error
+	// Keyboard заменяет клавиатуру по умолчанию с кнопками навигации.
+	Keyboard ReplyMarkup
+}
+
+// Пример использования
+// type signup struct {
+// 	Name  string
+// 	Phone string
+// 	Email string `form:"email"`
+// 	Birth time.Time
+// }
+// form := NewForm(
+// 	FormField{Name: "Name", Label: "Имя", Prompt: "Как вас зовут?"},
+// 	FormField{Name: "Phone", Label: "Телефон", Prompt: "Отправьте номер телефона", Type: FieldPhone},
+// 	FormField{Name: "email", Label: "E-mail", Prompt: "Ваш e-mail", Type: FieldEmail, Optional: true},
+// 	FormField{Name: "Birth", Label: "Дата рождения", Prompt: "Дата рождения (ДД.ММ.ГГГГ)", Type: FieldDate},
+// )
+// var data signup
+// if err := form.Run(c, &data); err != nil {
+// 	return err
+// }
+
+// Form проводит пользователя по полям по очереди, позволяет вернуться назад,
+// пропустить необязательное поле или отменить заполнение, показывает сводку
+// с inline-кнопкой подтверждения и заполняет структуру.
+type Form struct {
+	Fields []FormField
+	// Timeout - время ожидания каждого ответа. 0 - без ограничения.
+	Timeout time.Duration
+	// DateLayout - формат даты для FieldDate.
+	DateLayout string
+
+	Back, Skip, Cancel, SendContact string
+	Summary, Confirm, Restart       string
+	Done, Cancelled                 string
+}
+
+// NewForm создаёт форму с русскими подписями кнопок по умолчанию.
+func NewForm(fields ...FormField) *Form {
+	return &Form{
+		Fields:      fields,
+		Timeout:     10 * time.Minute,
+		DateLayout:  "02.01.2006",
+		Back:        "« Назад",
+		Skip:        "Пропустить",
+		Cancel:      "Отмена",
+		SendContact: "📱 Отправить контакт",
+		Summary:     "Проверьте данные:",
+		Confirm:     "✅ Подтвердить",
+		Restart:     "✏️ Заполнить заново",
+		Done:        "Готово!",
+		Cancelled:   "Заполнение отменено.",
+	}
+}
+
+// Run заполняет форму в диалоге с пользователем обновления c и записывает
+// результат в dst (указатель на структуру). Пропущенные поля остаются нулевыми.
+func (f *Form) Run(c *Ctx, dst any) error {
+	target := reflect.ValueOf(dst)
+	if target.Kind() != reflect.Ptr || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("form: expected pointer to struct, got %T", dst)
+	}
+	// Поля проверяются до первого вопроса, чтобы ошибка в описании формы
+	// не обнаружилась только после того, как пользователь всё заполнил.
+	targets := make([]reflect.Value, len(f.Fields))
+	for i, field := range f.Fields {
+		index, err := formFieldIndex(target.Elem().Type(), field)
+		if err != nil {
+			return err
+		}
+		targets[i] = target.Elem().Field(index)
+	}
+
+	values := make([]any, len(f.Fields))
+	for {
+		if err := f.fill(c, values, targets); err != nil {
+			return err
+		}
+
+		confirmed, err := f.confirm(c, values)
+		if err != nil {
+			return err
+		}
+		if confirmed {
+			break
+		}
+	}
+
+	for i, field := range f.Fields {
+		if values[i] == nil {
+			continue
+		}
+		if err := setFormField(targets[i], field, values[i]); err != nil {
+			return err
+		}
+	}
+	c.Reply(f.Done, Utils{Delete: &DeleteKeyboard{}})
+	return nil
+}
+
+// fill опрашивает пользователя по всем полям. targets - поля структуры, в которые
+// попадут ответы: по ним проверяется, что число помещается в тип поля.
+func (f *Form) fill(c *Ctx, values []any, targets []reflect.Value) error {
+	isMessage := func(update Update) bool {
+		return update.Message != nil
+	}
+
+	prompt := ""
+	for i := 0; i < len(f.Fields); {
+		field := f.Fields[i]
+		if prompt == "" {
+			prompt = field.Prompt
+		}
+		utils := Utils{Markup: f.keyboard(field, i)}
+
+		update, err := c.waitAfter(f.Timeout, isMessage, func() {
+			c.Reply(prompt, utils)
+		})
+		if err != nil {
+			return err
+		}
+		prompt = ""
+
+		message := update.Message
+		switch {
+		case message.Text == f.Cancel || message.Text == "/cancel":
+			c.Reply(f.Cancelled, Utils{Delete: &DeleteKeyboard{}})
+			return ErrFormCancelled
+		case message.Text == f.Back && i > 0:
+			i--
+			continue
+		case message.Text == f.Skip && field.Optional:
+			values[i] = nil
+			i++
+			continue
+		}
+
+		value, err := f.parse(field, message)
+		if number, ok := value.(int64); ok && targets[i].OverflowInt(number) {
+			err = errors.New("Слишком большое число")
+		}
+		if err == nil && field.Validate != nil {
+			err = field.Validate(value)
+		}
+		if err != nil {
+			prompt = err.Error()
+			continue
+		}
+		values[i] = value
+		i++
+	}
+	return nil
+}
+
+// confirm показывает сводку и ждёт нажатия "Подтвердить" или "Заполнить заново".
+func (f *Form) confirm(c *Ctx, values []any) (bool, error) {
+	var summary strings.Builder
+	summary.WriteString(f.Summary)
+	for i, field := range f.Fields {
+		label := field.Label
+		if label == "" {
+			label = field.Name
+		}
+		summary.WriteString("\n" + label + ": " + f.format(values[i]))
+	}
+
+	markup, err := NewInlineKeyboard().
+		Row(InlineCallback(f.Confirm, "form:confirm")).
+		Row(InlineCallback(f.Restart, "form:restart"), InlineCallback(f.Cancel, "form:cancel")).
+		Build()
+	if err != nil {
+		return false, err
+	}
+
+	// Фильтр выполняется в горутине диспетчера, поэтому ID сводки читается атомарно.
+	var messageID atomic.Int64
+	update, err := c.waitAfter(f.Timeout, func(update Update) bool {
+		query := update.CallbackQuery
+		return query != nil && query.Message != nil && query.Message.MessageID == messageID.Load() &&
+			strings.HasPrefix(query.Data, "form:")
+	}, func() {
+		messageID.Store(int64(c.Reply(summary.String(), Utils{Inline: markup})))
+	})
+	if err != nil {
+		return false, err
+	}
+
+	query := update.CallbackQuery
+	c.Bot.AnswerCallbackQuery(query.ID, "", "false")
+	c.Bot.EditMessage(query.Message.Chat.ID, query.Message.MessageID, summary.String(), Utils{})
+
+	switch query.Data {
+	case "form:confirm":
+		return true, nil
+	case "form:cancel":
+		c.Reply(f.Cancelled, Utils{Delete: &DeleteKeyboard{}})
+		return false, ErrFormCancelled
+	}
+	return false, nil
+}
+
+func (f *Form) keyboard(field FormField, index int) ReplyMarkup {
+	if field.Keyboard != nil {
+		return field.Keyboard
+	}
+
+	keyboard := NewReplyKeyboard().Resize()
+	if field.Type == FieldPhone {
+		keyboard.Row(ReplyContact(f.SendContact))
+	}
+	var nav []ReplyKeyboardButton
+	if index > 0 {
+		nav = append(nav, ReplyButton(f.Back))
+	}
+	if field.Optional {
+		nav = append(nav, ReplyButton(f.Skip))
+	}
+	nav = append(nav, ReplyButton(f.Cancel))
+	keyboard.Row(nav...)
+
+	markup, _ := keyboard.Build()
+	return markup
+}
+
+func (f *Form) parse(field FormField, message *Message) (any, error) {
+	text := strings.TrimSpace(message.Text)
+
+	switch field.Type {
+	case FieldInt:
+		value, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, errors.New("Введите целое число")
+		}
+		return value, nil
+	case FieldEmail:
+		address, err := mail.ParseAddress(text)
+		if err != nil || address.Address != text {
+			return nil, errors.New("Введите корректный e-mail")
+		}
+		return text, nil
+	case FieldPhone:
+		if message.Contact != nil {
+			return message.Contact.PhoneNumber, nil
+		}
+		if !phonePattern.MatchString(text) {
+			return nil, errors.New("Отправьте контакт кнопкой или введите номер телефона")
+		}
+		return text, nil
+	case FieldDate:
+		value, err := time.ParseInLocation(f.DateLayout, text, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("Введите дату в формате %s", time.Date(2006, 1, 2, 0, 0, 0, 0, time.Local).Format(f.DateLayout))
+		}
+		return value, nil
+	case FieldPhoto:
+		if len(message.Photo) == 0 {
+			return nil, errors.New("Отправьте фотографию")
+		}
+		return message.Photo[len(message.Photo)-1].FileID, nil
+	}
+
+	if text == "" {
+		return nil, errors.New("Отправьте текст")
+	}
+	return text, nil
+}
+
+func (f *Form) format(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "—"
+	case time.Time:
+		return v.Format(f.DateLayout)
+	}
+	return fmt.Sprint(value)
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// formFieldIndex находит поле структуры с именем или тегом `form:"name"` и
+// проверяет, что его тип подходит для значения field.Type.
+func formFieldIndex(t reflect.Type, field FormField) (int, error) {
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if !structField.IsExported() || (structField.Name != field.Name && structField.Tag.Get("form") != field.Name) {
+			continue
+		}
+
+		kind := structField.Type.Kind()
+		var ok bool
+		switch field.Type {
+		case FieldInt:
+			ok = kind >= reflect.Int && kind <= reflect.Int64
+		case FieldDate:
+			ok = structField.Type == timeType
+		default:
+			ok = kind == reflect.String
+		}
+		if !ok {
+			return 0, fmt.Errorf("form: field %s: type %s does not fit %s", field.Name, structField.Type, field.Type)
+		}
+		return i, nil
+	}
+	return 0, fmt.Errorf("form: struct %s has no field %s", t, field.Name)
+}
+
+// setFormField записывает разобранное значение в поле, проверенное formFieldIndex.
+func setFormField(target reflect.Value, field FormField, value any) error {
+	switch v := value.(type) {
+	case string:
+		target.SetString(v)
+	case int64:
+		if target.OverflowInt(v) {
+			return fmt.Errorf("form: field %s: %d overflows %s", field.Name, v, target.Type())
+		}
+		target.SetInt(v)
+	case time.Time:
+		target.Set(reflect.ValueOf(v))
+	default:
+		return fmt.Errorf("form: field %s: unexpected value %T", field.Name, value)
+	}
+	return nil
+}
+
+func (t FieldType) String() string {
+	switch t {
+	case FieldText:
+		return "FieldText"
+	case FieldInt:
+		return "FieldInt"
+	case FieldEmail:
+		return "FieldEmail"
+	case FieldPhone:
+		return "FieldPhone"
+	case FieldDate:
+		return "FieldDate"
+	case FieldPhoto:
+		return "FieldPhoto"
+	}
+	return fmt.Sprintf("FieldType(%d)", int(t))
+}
@@ -0,0 +1,65 @@
+package LCB
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type formTarget struct {
+	Name  string
+	Age   int8
+	Email string `form:"email"`
+	Birth time.Time
+}
+
+func TestFormRunChecksFieldsBeforePrompting(t *testing.T) {
+	c := newCtx(NewBot("", false), Update{})
+
+	forms := map[string]*Form{
+		"missing field": NewForm(FormField{Name: "Phone"}),
+		"int to string": NewForm(FormField{Name: "Name", Type: FieldInt}),
+		"text to int":   NewForm(FormField{Name: "Age", Type: FieldText}),
+		"date to text":  NewForm(FormField{Name: "email", Type: FieldDate}),
+	}
+	for name, form := range forms {
+		var dst formTarget
+		// Если проверка не сработает, Run попытается отправить вопрос и ждать ответа.
+		if err := form.Run(c, &dst); err == nil || err == ErrWaitCancelled {
+			t.Errorf("%s: Run() = %v, want field error", name, err)
+		}
+	}
+}
+
+func TestSetFormField(t *testing.T) {
+	var dst formTarget
+	target := reflect.ValueOf(&dst).Elem()
+	birth := time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	fields := []struct {
+		field FormField
+		value any
+	}{
+		{FormField{Name: "Name"}, "alice"},
+		{FormField{Name: "Age", Type: FieldInt}, int64(42)},
+		{FormField{Name: "email", Type: FieldEmail}, "a@example.com"},
+		{FormField{Name: "Birth", Type: FieldDate}, birth},
+	}
+	for _, f := range fields {
+		index, err := formFieldIndex(target.Type(), f.field)
+		if err != nil {
+			t.Fatalf("formFieldIndex(%s): %v", f.field.Name, err)
+		}
+		if err := setFormField(target.Field(index), f.field, f.value); err != nil {
+			t.Fatalf("setFormField(%s): %v", f.field.Name, err)
+		}
+	}
+
+	want := formTarget{Name: "alice", Age: 42, Email: "a@example.com", Birth: birth}
+	if dst != want {
+		t.Fatalf("got %+v, want %+v", dst, want)
+	}
+	if err := setFormField(target.Field(1), FormField{Name: "Age"}, int64(1000)); err == nil {
+		t.Fatal("int8 overflow was accepted")
+	}
+}
@@ -0,0 +1,474 @@
+package LCB
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrFSMNotConfigured возвращается из Ctx.FSM, если у бота не создан FSM.
+	ErrFSMNotConfigured = errors.New("fsm is not configured, call Bot.FSM first")
+	// ErrFSMTransition возвращается при переходе, не объявленном через FSM.Transition.
+	ErrFSMTransition = errors.New("fsm transition is not allowed")
+	// ErrFSMUnknownState возвращается при переходе в незарегистрированное состояние.
+	ErrFSMUnknownState = errors.New("fsm state is not registered")
+)
+
+// FSMKey определяет диалог: пользователя в конкретном чате.
+type FSMKey struct {
+	ChatID int64
+	UserID int64
+}
+
+// FSMRecord - сохранённое состояние диалога.
+type FSMRecord struct {
+	State   string
+	Data    map[string]any
+	Updated time.Time
+}
+
+// FSMStorage хранит состояния диалогов. Get возвращает nil, nil, если диалога нет.
+type FSMStorage interface {
+	Get(key FSMKey) (*FSMRecord, error)
+	Set(key FSMKey, record *FSMRecord) error
+	Delete(key FSMKey) error
+}
+
+// FSMExpirer - необязательное расширение FSMStorage для FSM.StartJanitor:
+// Expired возвращает ключи диалогов, не обновлявшихся с момента before.
+type FSMExpirer interface {
+	Expired(before time.Time) ([]FSMKey, error)
+}
+
+// Пример использования
+// fsm := bot.FSM(nil)
+// fsm.Timeout = 10 * time.Minute
+// fsm.OnTimeout(func(c *Ctx, state string) {
+// 	c.Reply("Диалог сброшен из-за бездействия")
+// })
+// fsm.StartJanitor(time.Minute)
+// fsm.CancelCommands("cancel")
+// fsm.State("name", func(c *Ctx) error {
+// 	c.FSM().Update("name", c.Message.Text)
+// 	c.Reply("Введите телефон")
+// 	return c.FSM().Set("phone")
+// })
+// fsm.State("phone", func(c *Ctx) error {
+// 	name, _ := c.FSM().Get("name")
+// 	c.Reply(fmt.Sprint("Спасибо, ", name))
+// 	return c.FSM().Finish()
+// })
+// bot.Handle(isRegisterCommand, func(c *Ctx) error {
+// 	c.Reply("Введите имя")
+// 	return c.FSM().Set("name")
+// })
+
+// FSM - конечный автомат диалогов. Пока у пользователя в чате есть состояние,
+// обновления от него сначала получает обработчик этого состояния, а остальные
+// обработчики бота не вызываются. Чтобы передать обновление дальше, обработчик
+// состояния может вернуть ErrContinue.
+type FSM struct {
+	bot     *Bot
+	storage FSMStorage
+
+	mu          sync.RWMutex
+	states      map[string]HandlerFunc
+	transitions map[string][]string
+	cancel      []string
+	onCancel    HandlerFunc
+	onTimeout   func(c *Ctx, state string)
+
+	stopJanitor chan struct{}
+	janitorDone chan struct{}
+
+	// Timeout - время бездействия, после которого диалог сбрасывается.
+	// Проверяется при следующем обновлении от пользователя, а если запущен
+	// StartJanitor - ещё и периодически в фоне. 0 - без ограничения.
+	Timeout time.Duration
+}
+
+// FSM создаёт автомат диалогов и подключает его к боту. Если storage равен nil,
+// состояния хранятся в памяти.
+func (b *Bot) FSM(storage FSMStorage) *FSM {
+	if storage == nil {
+		storage = NewMemoryFSMStorage()
+	}
+	f := &FSM{
+		bot:         b,
+		storage:     storage,
+		states:      make(map[string]HandlerFunc),
+		transitions: make(map[string][]string),
+	}
+	b.handlersMu.Lock()
+	b.fsm = f
+	b.handlersMu.Unlock()
+	return f
+}
+
+// State регистрирует обработчик состояния name.
+func (f *FSM) State(name string, handler HandlerFunc) {
+	f.mu.Lock()
+	f.states[name] = handler
+	f.mu.Unlock()
+}
+
+// Transition объявляет допустимые переходы из состояния from. Если для
+// состояния переходы не объявлены, из него можно перейти в любое.
+func (f *FSM) Transition(from string, to ...string) {
+	f.mu.Lock()
+	f.transitions[from] = append(f.transitions[from], to...)
+	f.mu.Unlock()
+}
+
+// CancelCommands задаёт команды (без "/"), которые в любом состоянии сбрасывают диалог.
+func (f *FSM) CancelCommands(names ...string) {
+	f.mu.Lock()
+	f.cancel = append(f.cancel, names...)
+	f.mu.Unlock()
+}
+
+// OnCancel задаёт обработчик, вызываемый после сброса диалога командой отмены.
+func (f *FSM) OnCancel(handler HandlerFunc) {
+	f.mu.Lock()
+	f.onCancel = handler
+	f.mu.Unlock()
+}
+
+// OnTimeout задаёт обработчик, вызываемый, когда диалог сброшен по Timeout.
+// Обновление, на котором это обнаружено, затем обрабатывается как обычно.
+// Если диалог сбросил janitor, Ctx.Update пуст, а заполнены только Chat и User.
+func (f *FSM) OnTimeout(handler func(c *Ctx, state string)) {
+	f.mu.Lock()
+	f.onTimeout = handler
+	f.mu.Unlock()
+}
+
+// dispatch передаёт обновление обработчику текущего состояния.
+// Возвращает true, если обновление обработано и дальше передаваться не должно.
+func (f *FSM) dispatch(c *Ctx, global []Middleware) bool {
+	key, ok := updateKey(c.Update)
+	if !ok {
+		return false
+	}
+
+	record, err := f.storage.Get(key)
+	if err != nil {
+		c.Bot.handleError(c, fmt.Errorf("fsm storage: %w", err))
+		return false
+	}
+	if record == nil {
+		return false
+	}
+
+	f.mu.RLock()
+	handler := f.states[record.State]
+	cancel := f.cancel
+	onCancel := f.onCancel
+	onTimeout := f.onTimeout
+	f.mu.RUnlock()
+
+	if f.Timeout > 0 && time.Since(record.Updated) > f.Timeout {
+		if err := f.storage.Delete(key); err != nil {
+			c.Bot.handleError(c, fmt.Errorf("fsm storage: %w", err))
+		}
+		if onTimeout != nil {
+			onTimeout(c, record.State)
+		}
+		return false
+	}
+
+	if cmd, ok := ParseCommand(c.Update.Message); ok && slices.ContainsFunc(cancel, func(name string) bool {
+		return c.Bot.MatchesCommand(cmd, name)
+	}) {
+		if err := f.storage.Delete(key); err != nil {
+			c.Bot.handleError(c, fmt.Errorf("fsm storage: %w", err))
+		}
+		if onCancel != nil {
+			f.run(c, chain(onCancel, global))
+		}
+		return true
+	}
+
+	if handler == nil {
+		return false
+	}
+	return f.run(c, chain(handler, global))
+}
+
+// StartJanitor запускает фоновый сброс диалогов, неактивных дольше Timeout,
+// раз в interval, чтобы OnTimeout срабатывал и для пользователей, которые
+// больше не пишут боту. Хранилище должно реализовывать FSMExpirer.
+// Janitor останавливается через StopJanitor или вместе с ботом.
+// Повторный вызов перезапускает janitor с новым интервалом.
+func (f *FSM) StartJanitor(interval time.Duration) error {
+	if _, ok := f.storage.(FSMExpirer); !ok {
+		return fmt.Errorf("fsm storage %T does not implement FSMExpirer", f.storage)
+	}
+	f.StopJanitor()
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	f.mu.Lock()
+	f.stopJanitor, f.janitorDone = stop, done
+	f.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				f.RemoveExpired()
+			case <-stop:
+				return
+			case <-f.bot.ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// StopJanitor останавливает janitor и ждёт его завершения.
+func (f *FSM) StopJanitor() {
+	f.mu.Lock()
+	stop, done := f.stopJanitor, f.janitorDone
+	f.stopJanitor, f.janitorDone = nil, nil
+	f.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// RemoveExpired сбрасывает диалоги, неактивные дольше Timeout, вызывает для
+// каждого OnTimeout и возвращает их число. Хранилище без FSMExpirer не проверяется.
+func (f *FSM) RemoveExpired() int {
+	expirer, ok := f.storage.(FSMExpirer)
+	if !ok || f.Timeout <= 0 {
+		return 0
+	}
+
+	f.mu.RLock()
+	onTimeout := f.onTimeout
+	f.mu.RUnlock()
+
+	keys, err := expirer.Expired(time.Now().Add(-f.Timeout))
+	if err != nil {
+		f.bot.handleError(newCtx(f.bot, Update{}), fmt.Errorf("fsm storage: %w", err))
+		return 0
+	}
+
+	removed := 0
+	for _, key := range keys {
+		c := newCtx(f.bot, Update{})
+		c.Chat = &Chat{ID: key.ChatID}
+		c.User = &User{ID: key.UserID}
+
+		// Пока шёл обход, пользователь мог продолжить диалог.
+		record, err := f.storage.Get(key)
+		if err == nil && (record == nil || time.Since(record.Updated) <= f.Timeout) {
+			continue
+		}
+		if err == nil {
+			err = f.storage.Delete(key)
+		}
+		if err != nil {
+			c.Bot.handleError(c, fmt.Errorf("fsm storage: %w", err))
+			continue
+		}
+
+		removed++
+		if onTimeout != nil {
+			if err := c.Bot.call(c, func(c *Ctx) error {
+				onTimeout(c, record.State)
+				return nil
+			}); err != nil {
+				c.Bot.handleError(c, err)
+			}
+		}
+	}
+	return removed
+}
+
+func (f *FSM) run(c *Ctx, handler HandlerFunc) bool {
+	err := c.Bot.call(c, handler)
+	if errors.Is(err, ErrContinue) {
+		return false
+	}
+	if err != nil && !errors.Is(err, ErrStop) {
+		c.Bot.handleError(c, err)
+	}
+	return true
+}
+
+// FSMContext управляет диалогом пользователя из обработчика.
+type FSMContext struct {
+	fsm *FSM
+	key FSMKey
+}
+
+// FSM возвращает диалог пользователя в чате текущего обновления.
+func (c *Ctx) FSM() *FSMContext {
+	c.Bot.handlersMu.RLock()
+	f := c.Bot.fsm
+	c.Bot.handlersMu.RUnlock()
+
+	key, _ := updateKey(c.Update)
+	return &FSMContext{fsm: f, key: key}
+}
+
+// Current возвращает текущее состояние или пустую строку, если диалога нет.
+func (s *FSMContext) Current() (string, error) {
+	record, err := s.record()
+	if err != nil || record == nil {
+		return "", err
+	}
+	return record.State, nil
+}
+
+// Set переводит диалог в состояние state, сохраняя накопленные данные.
+func (s *FSMContext) Set(state string) error {
+	record, err := s.record()
+	if err != nil {
+		return err
+	}
+
+	s.fsm.mu.RLock()
+	_, known := s.fsm.states[state]
+	var allowed []string
+	if record != nil {
+		allowed = s.fsm.transitions[record.State]
+	}
+	s.fsm.mu.RUnlock()
+
+	if !known {
+		return fmt.Errorf("%w: %q", ErrFSMUnknownState, state)
+	}
+	if len(allowed) > 0 && !slices.Contains(allowed, state) {
+		return fmt.Errorf("%w: %q -> %q", ErrFSMTransition, record.State, state)
+	}
+
+	if record == nil {
+		record = &FSMRecord{Data: make(map[string]any)}
+	}
+	record.State = state
+	record.Updated = time.Now()
+	return s.fsm.storage.Set(s.key, record)
+}
+
+// Get возвращает значение, сохранённое в диалоге.
+func (s *FSMContext) Get(name string) (any, bool) {
+	record, err := s.record()
+	if err != nil || record == nil {
+		return nil, false
+	}
+	value, ok := record.Data[name]
+	return value, ok
+}
+
+// Data возвращает все данные диалога.
+func (s *FSMContext) Data() (map[string]any, error) {
+	record, err := s.record()
+	if err != nil || record == nil {
+		return nil, err
+	}
+	return record.Data, nil
+}
+
+// Update сохраняет значение в данных диалога. Диалог должен быть начат через Set.
+func (s *FSMContext) Update(name string, value any) error {
+	record, err := s.record()
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return fmt.Errorf("%w: no active dialog", ErrFSMUnknownState)
+	}
+	if record.Data == nil {
+		record.Data = make(map[string]any)
+	}
+	record.Data[name] = value
+	record.Updated = time.Now()
+	return s.fsm.storage.Set(s.key, record)
+}
+
+// Finish завершает диалог и удаляет его данные.
+func (s *FSMContext) Finish() error {
+	if s.fsm == nil {
+		return ErrFSMNotConfigured
+	}
+	return s.fsm.storage.Delete(s.key)
+}
+
+func (s *FSMContext) record() (*FSMRecord, error) {
+	if s.fsm == nil {
+		return nil, ErrFSMNotConfigured
+	}
+	return s.fsm.storage.Get(s.key)
+}
+
+// MemoryFSMStorage хранит состояния диалогов в памяти процесса.
+type MemoryFSMStorage struct {
+	mu      sync.Mutex
+	records map[FSMKey]FSMRecord
+}
+
+func NewMemoryFSMStorage() *MemoryFSMStorage {
+	return &MemoryFSMStorage{records: make(map[FSMKey]FSMRecord)}
+}
+
+func (s *MemoryFSMStorage) Get(key FSMKey) (*FSMRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok {
+		return nil, nil
+	}
+	record.Data = copyData(record.Data)
+	return &record, nil
+}
+
+func (s *MemoryFSMStorage) Set(key FSMKey, record *FSMRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *record
+	stored.Data = copyData(record.Data)
+	s.records[key] = stored
+	return nil
+}
+
+func (s *MemoryFSMStorage) Delete(key FSMKey) error {
+	s.mu.Lock()
+	delete(s.records, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// Expired реализует FSMExpirer.
+func (s *MemoryFSMStorage) Expired(before time.Time) ([]FSMKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []FSMKey
+	for key, record := range s.records {
+		if record.Updated.Before(before) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func copyData(data map[string]any) map[string]any {
+	result := make(map[string]any, len(data))
+	for key, value := range data {
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package LCB
+
+import (
+	"testing"
+	"time"
+)
+
+type plainFSMStorage struct {
+	FSMStorage
+}
+
+func TestFSMJanitorResetsInactiveDialogs(t *testing.T) {
+	b := NewBot("", false)
+	storage := NewMemoryFSMStorage()
+	fsm := b.FSM(storage)
+	fsm.Timeout = time.Minute
+	fsm.State("name", func(c *Ctx) error { return nil })
+
+	type timeout struct {
+		key   FSMKey
+		state string
+	}
+	timeouts := make(chan timeout, 2)
+	fsm.OnTimeout(func(c *Ctx, state string) {
+		timeouts <- timeout{FSMKey{ChatID: c.ChatID(), UserID: c.User.ID}, state}
+	})
+
+	stale := FSMKey{ChatID: -100, UserID: 1}
+	active := FSMKey{ChatID: -100, UserID: 2}
+	storage.Set(stale, &FSMRecord{State: "name", Updated: time.Now().Add(-time.Hour)})
+	storage.Set(active, &FSMRecord{State: "name", Updated: time.Now()})
+
+	if err := fsm.StartJanitor(10 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	defer fsm.StopJanitor()
+
+	select {
+	case got := <-timeouts:
+		if got.key != stale || got.state != "name" {
+			t.Fatalf("OnTimeout(%v, %q), want %v, %q", got.key, got.state, stale, "name")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("janitor did not reset the inactive dialog")
+	}
+
+	if record, _ := storage.Get(stale); record != nil {
+		t.Fatalf("stale dialog is still stored: %+v", record)
+	}
+	if record, _ := storage.Get(active); record == nil {
+		t.Fatal("active dialog was reset")
+	}
+	select {
+	case got := <-timeouts:
+		t.Fatalf("unexpected OnTimeout for %v", got.key)
+	default:
+	}
+}
+
+func TestFSMJanitorRequiresExpirer(t *testing.T) {
+	fsm := NewBot("", false).FSM(plainFSMStorage{NewMemoryFSMStorage()})
+	if err := fsm.StartJanitor(time.Minute); err == nil {
+		t.Fatal("StartJanitor accepted storage without FSMExpirer")
+	}
+}
+
+func TestFSMJanitorStopsWithBot(t *testing.T) {
+	b := NewBot("", false)
+	fsm := b.FSM(nil)
+	if err := fsm.StartJanitor(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	done := fsm.janitorDone
+
+	b.cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("janitor kept running after the bot stopped")
+	}
+	fsm.StopJanitor()
+}
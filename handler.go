@@ -0,0 +1,256 @@
+package LCB
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrContinue, возвращённый обработчиком, передаёт обновление следующему
+	// подходящему обработчику той же группы.
+	ErrContinue = errors.New("continue to next handler")
+	// ErrStop, возвращённый обработчиком, прекращает обработку обновления:
+	// группы с меньшим приоритетом не запускаются.
+	ErrStop = errors.New("stop propagation")
+)
+
+// HandlerFunc - обработчик обновления. Ctx общий для всех обработчиков и middleware одного обновления.
+type HandlerFunc func(c *Ctx) error
+
+// Middleware оборачивает обработчик. Чтобы прервать обработку, middleware
+// может не вызывать next.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Пример использования
+// admins := bot.Group(OnlyAdmins)
+// admins.Use(Timing(func(c *Ctx, d time.Duration, err error) {
+// 	log.Println("admin handler took", d)
+// }))
+// admins.Handle(isBanCommand, banHandler)
+
+// Group объединяет обработчики с общими middleware. Для каждого обновления
+// в группе срабатывает только первый подходящий обработчик, а группы
+// перебираются по убыванию приоритета.
+type Group struct {
+	bot         *Bot
+	priority    int
+	middlewares []Middleware
+	handlers    []Handler
+}
+
+// Group создаёт новую группу обработчиков с приоритетом 0 и переданными middleware.
+func (b *Bot) Group(middlewares ...Middleware) *Group {
+	g := &Group{bot: b, middlewares: middlewares}
+	b.handlersMu.Lock()
+	b.groups = append(b.groups, g)
+	b.sortGroups()
+	b.handlersMu.Unlock()
+	return g
+}
+
+// Fallback задаёт обработчик обновлений, для которых не подошёл ни один обработчик.
+// Глобальные middleware применяются и к нему.
+func (b *Bot) Fallback(handler HandlerFunc) {
+	b.handlersMu.Lock()
+	b.fallback = handler
+	b.handlersMu.Unlock()
+}
+
+// Priority задаёт приоритет группы. Группы с большим приоритетом обрабатывают
+// обновление раньше, при равном приоритете - в порядке создания.
+// Группа по умолчанию имеет приоритет 0.
+func (g *Group) Priority(priority int) *Group {
+	g.bot.handlersMu.Lock()
+	g.priority = priority
+	g.bot.sortGroups()
+	g.bot.handlersMu.Unlock()
+	return g
+}
+
+// Use добавляет middleware, которые применяются ко всем обработчикам бота.
+func (b *Bot) Use(middlewares ...Middleware) {
+	b.handlersMu.Lock()
+	b.middlewares = append(b.middlewares, middlewares...)
+	b.handlersMu.Unlock()
+}
+
+// Handle регистрирует обработчик в группе по умолчанию.
+func (b *Bot) Handle(filter func(update Update) bool, handler HandlerFunc, middlewares ...Middleware) {
+	b.defaultGroup.Handle(filter, handler, middlewares...)
+}
+
+// Use добавляет middleware, которые применяются ко всем обработчикам группы.
+func (g *Group) Use(middlewares ...Middleware) {
+	g.bot.handlersMu.Lock()
+	g.middlewares = append(g.middlewares, middlewares...)
+	g.bot.handlersMu.Unlock()
+}
+
+// Handle регистрирует обработчик в группе. middlewares применяются только к нему.
+func (g *Group) Handle(filter func(update Update) bool, handler HandlerFunc, middlewares ...Middleware) {
+	g.add(Handler{Filter: filter, Func: handler, Middlewares: middlewares})
+}
+
+// handleCallback регистрирует в группе по умолчанию обработчик нажатий кнопок,
+// на которые отвечается автоматически, даже если middleware не вызвало обработчик.
+func (b *Bot) handleCallback(filter func(update Update) bool, handler HandlerFunc, middlewares ...Middleware) {
+	b.defaultGroup.add(Handler{Filter: filter, Func: handler, Middlewares: middlewares, autoAnswer: true})
+}
+
+func (g *Group) add(handler Handler) {
+	g.bot.handlersMu.Lock()
+	g.handlers = append(g.handlers, handler)
+	g.bot.handlersMu.Unlock()
+}
+
+func (g *Group) AddHandler(filter func(update Update) bool, callback func(update Update)) {
+	g.bot.handlersMu.Lock()
+	g.handlers = append(g.handlers, Handler{Filter: filter, Callback: callback})
+	g.bot.handlersMu.Unlock()
+}
+
+// Timing возвращает middleware, сообщающее длительность и результат каждого обработчика.
+func Timing(report func(c *Ctx, duration time.Duration, err error)) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Ctx) error {
+			start := time.Now()
+			err := next(c)
+			report(c, time.Since(start), err)
+			return err
+		}
+	}
+}
+
+// handlerFunc приводит обработчик к HandlerFunc.
+func (h Handler) handlerFunc() HandlerFunc {
+	if h.Func != nil {
+		return h.Func
+	}
+	callback := h.Callback
+	return func(c *Ctx) error {
+		callback(c.Update)
+		return nil
+	}
+}
+
+// chain оборачивает fn в middleware так, что первое в списке выполняется первым.
+func chain(fn HandlerFunc, middlewares ...[]Middleware) HandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		for j := len(middlewares[i]) - 1; j >= 0; j-- {
+			fn = middlewares[i][j](fn)
+		}
+	}
+	return fn
+}
+
+// widgetPriority - приоритет группы встроенных компонентов (пагинатор, календарь):
+// их кнопки обрабатываются раньше пользовательских обработчиков.
+const widgetPriority = 1 << 20
+
+// handleWidget регистрирует обработчик встроенного компонента. Обработанное
+// им обновление дальше не передаётся.
+func (b *Bot) handleWidget(filter func(update Update) bool, callback func(c *Ctx)) {
+	b.handlersMu.Lock()
+	if b.widgets == nil {
+		b.widgets = &Group{bot: b, priority: widgetPriority}
+		b.groups = append(b.groups, b.widgets)
+		b.sortGroups()
+	}
+	b.handlersMu.Unlock()
+
+	b.widgets.Handle(filter, func(c *Ctx) error {
+		callback(c)
+		return ErrStop
+	})
+}
+
+func (b *Bot) sortGroups() {
+	sort.SliceStable(b.groups, func(i, j int) bool {
+		return b.groups[i].priority > b.groups[j].priority
+	})
+}
+
+type groupSnapshot struct {
+	priority    int
+	middlewares []Middleware
+	handlers    []Handler
+}
+
+// dispatch передаёт обновление воркеру или, без настроенного пула, в отдельную горутину.
+func (b *Bot) dispatch(update Update) {
+	if b.deliverToWaiter(update) {
+		return
+	}
+
+	b.handlersMu.RLock()
+	global := b.middlewares
+	fallback := b.fallback
+	fsm := b.fsm
+	groups := make([]groupSnapshot, len(b.groups))
+	for i, group := range b.groups {
+		groups[i] = groupSnapshot{priority: group.priority, middlewares: group.middlewares, handlers: group.handlers}
+	}
+	b.handlersMu.RUnlock()
+
+	b.running.Add(1)
+	job := func(release func()) {
+		defer b.running.Done()
+		b.runHandlers(update, global, groups, fallback, fsm, release)
+	}
+	if b.dispatcher == nil {
+		go job(nil)
+		return
+	}
+	b.dispatcher.submit(update, job)
+}
+
+// runHandlers перебирает группы по приоритету и запускает в каждой первый подходящий обработчик.
+// Обработчики состояний FSM получают обновление после встроенных компонентов, но раньше остальных групп.
+// release освобождает воркер пула, пока обработчик ждёт ответа (см. Ctx.Wait).
+func (b *Bot) runHandlers(update Update, global []Middleware, groups []groupSnapshot, fallback HandlerFunc, fsm *FSM, release func()) {
+	c := newCtx(b, update)
+	c.release = release
+	matched := false
+
+	for _, group := range groups {
+		if fsm != nil && group.priority < widgetPriority {
+			if fsm.dispatch(c, global) {
+				return
+			}
+			fsm = nil
+		}
+
+		for _, handler := range group.handlers {
+			if !b.matches(c, handler.Filter) {
+				continue
+			}
+
+			err := b.call(c, chain(handler.handlerFunc(), global, group.middlewares, handler.Middlewares))
+			if errors.Is(err, ErrContinue) {
+				continue
+			}
+			matched = true
+			if handler.autoAnswer && update.CallbackQuery != nil {
+				c.answerIfNeeded()
+			}
+			if errors.Is(err, ErrStop) {
+				return
+			}
+			if err != nil {
+				b.handleError(c, err)
+			}
+			break
+		}
+	}
+
+	if fsm != nil && fsm.dispatch(c, global) {
+		return
+	}
+
+	if !matched && fallback != nil {
+		if err := b.call(c, chain(fallback, global)); err != nil && !errors.Is(err, ErrStop) && !errors.Is(err, ErrContinue) {
+			b.handleError(c, err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package LCB
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func messageUpdate(text string) Update {
+	return Update{Message: &Message{
+		Text: text,
+		From: &User{ID: 1},
+		Chat: &Chat{ID: 1},
+	}}
+}
+
+func TestFallbackRunsWhenAllHandlersContinue(t *testing.T) {
+	b := NewBot("", false)
+
+	var calls, fallbacks atomic.Int32
+	matchAll := func(update Update) bool { return true }
+	b.Handle(matchAll, func(c *Ctx) error {
+		calls.Add(1)
+		return ErrContinue
+	})
+	b.Group().Handle(matchAll, func(c *Ctx) error {
+		calls.Add(1)
+		return ErrContinue
+	})
+	b.Fallback(func(c *Ctx) error {
+		fallbacks.Add(1)
+		return nil
+	})
+
+	b.dispatch(messageUpdate("hello"))
+	b.running.Wait()
+
+	if calls.Load() != 2 {
+		t.Fatalf("handlers called %d times, want 2", calls.Load())
+	}
+	if fallbacks.Load() != 1 {
+		t.Fatalf("fallback called %d times, want 1", fallbacks.Load())
+	}
+}
+
+func TestFallbackSkippedWhenHandlerProcessesUpdate(t *testing.T) {
+	b := NewBot("", false)
+
+	var fallbacks atomic.Int32
+	matchAll := func(update Update) bool { return true }
+	b.Handle(matchAll, func(c *Ctx) error {
+		return ErrContinue
+	})
+	b.Handle(matchAll, func(c *Ctx) error {
+		return nil
+	})
+	b.Fallback(func(c *Ctx) error {
+		fallbacks.Add(1)
+		return nil
+	})
+
+	b.dispatch(messageUpdate("hello"))
+	b.running.Wait()
+
+	if fallbacks.Load() != 0 {
+		t.Fatalf("fallback called %d times, want 0", fallbacks.Load())
+	}
+}
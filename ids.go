@@ -0,0 +1,171 @@
+package LCB
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrNoIDGenerator возвращается из AddElement, если генератор ключей не задан.
+	ErrNoIDGenerator = errors.New("state has no id generator")
+	// ErrIDOverflow возвращается, когда генератор исчерпал диапазон ключей.
+	ErrIDOverflow = errors.New("id generator overflow")
+	// ErrIDCollision возвращается, если выданный ключ уже занят, например через SetElement.
+	ErrIDCollision = errors.New("generated id is already in use")
+)
+
+// IDGenerator выдаёт ключи для NewState.AddElement. Реализации должны быть
+// безопасны для одновременного вызова.
+type IDGenerator[K comparable] interface {
+	NextID() (K, error)
+}
+
+// idObserver реализуют генераторы, которым нужно знать уже занятые ключи,
+// например после загрузки состояния из хранилища.
+type idObserver[K comparable] interface {
+	Observe(key K)
+}
+
+// Диапазон ключей AddElement по умолчанию для int64. Он лежит ниже всех ID
+// пользователей и чатов Telegram (ID супергрупп начинаются с -100 и занимают 13 цифр),
+// поэтому в одном NewState можно хранить и элементы по userID через SetElement,
+// и элементы с ключами от AddElement.
+const (
+	DefaultStateIDStart int64 = -99999999999999
+	DefaultStateIDEnd   int64 = -10000000000000
+)
+
+func defaultIDs[K comparable]() IDGenerator[K] {
+	var ids any
+	switch any(*new(K)).(type) {
+	case int64:
+		ids = NewSequentialIDRange(DefaultStateIDStart, DefaultStateIDEnd)
+	case string:
+		ids = NewUUIDs()
+	default:
+		return nil
+	}
+	return ids.(IDGenerator[K])
+}
+
+// SequentialIDs выдаёт ключи по возрастанию в диапазоне [start, end]. Счётчик атомарный,
+// поэтому генератор можно делить между несколькими NewState.
+type SequentialIDs struct {
+	start int64
+	end   int64
+	last  atomic.Int64
+}
+
+// NewSequentialIDs создаёт генератор, первым выдающий start (start > math.MinInt64).
+func NewSequentialIDs(start int64) *SequentialIDs {
+	return NewSequentialIDRange(start, math.MaxInt64)
+}
+
+// NewSequentialIDRange создаёт генератор ключей от start до end включительно.
+// После end NextID возвращает ErrIDOverflow.
+func NewSequentialIDRange(start, end int64) *SequentialIDs {
+	s := &SequentialIDs{start: start, end: end}
+	s.last.Store(start - 1)
+	return s
+}
+
+func (s *SequentialIDs) NextID() (int64, error) {
+	for {
+		last := s.last.Load()
+		if last >= s.end {
+			return 0, ErrIDOverflow
+		}
+		if s.last.CompareAndSwap(last, last+1) {
+			return last + 1, nil
+		}
+	}
+}
+
+// Observe сдвигает счётчик за уже занятый ключ. Ключи вне диапазона генератора
+// (например ID пользователей) не влияют на счётчик.
+func (s *SequentialIDs) Observe(key int64) {
+	if key < s.start || key > s.end {
+		return
+	}
+	for {
+		last := s.last.Load()
+		if key <= last || s.last.CompareAndSwap(last, key) {
+			return
+		}
+	}
+}
+
+// UUIDs выдаёт случайные UUID версии 4.
+type UUIDs struct{}
+
+func NewUUIDs() UUIDs {
+	return UUIDs{}
+}
+
+func (UUIDs) NextID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+const (
+	snowflakeNodeBits     = 10
+	snowflakeSequenceBits = 12
+	snowflakeTimeBits     = 63 - snowflakeNodeBits - snowflakeSequenceBits
+	snowflakeMaxNode      = 1<<snowflakeNodeBits - 1
+	snowflakeMaxSequence  = 1<<snowflakeSequenceBits - 1
+)
+
+// SnowflakeEpoch - начало отсчёта времени в ключах Snowflake.
+var SnowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Snowflake выдаёт возрастающие по времени ключи: миллисекунды от SnowflakeEpoch,
+// номер узла и порядковый номер внутри миллисекунды. Ключи разных узлов не пересекаются.
+type Snowflake struct {
+	mu       sync.Mutex
+	node     int64
+	last     int64
+	sequence int64
+}
+
+// NewSnowflake создаёт генератор для узла node (от 0 до 1023).
+func NewSnowflake(node int64) (*Snowflake, error) {
+	if node < 0 || node > snowflakeMaxNode {
+		return nil, fmt.Errorf("snowflake node must be in [0, %d], got %d", snowflakeMaxNode, node)
+	}
+	return &Snowflake{node: node}, nil
+}
+
+func (s *Snowflake) NextID() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Since(SnowflakeEpoch).Milliseconds()
+	if now <= s.last {
+		// При переводе часов назад или исчерпании номеров в миллисекунде
+		// продолжаем от последней выданной, чтобы ключи только возрастали.
+		now = s.last
+		s.sequence++
+		if s.sequence > snowflakeMaxSequence {
+			now++
+			s.sequence = 0
+		}
+	} else {
+		s.sequence = 0
+	}
+	if now < 0 || now >= 1<<snowflakeTimeBits {
+		return 0, ErrIDOverflow
+	}
+	s.last = now
+
+	return now<<(snowflakeNodeBits+snowflakeSequenceBits) | s.node<<snowflakeSequenceBits | s.sequence, nil
+}
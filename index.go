@@ -0,0 +1,208 @@
+package LCB
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var (
+	// ErrIndexExists возвращается при повторном объявлении индекса с тем же именем.
+	ErrIndexExists = errors.New("state index already exists")
+	// ErrIndexNotFound возвращается при поиске по необъявленному индексу.
+	ErrIndexNotFound = errors.New("state index not found")
+	// ErrUniqueViolation возвращается, если значение уникального индекса уже занято другим ключом.
+	ErrUniqueViolation = errors.New("unique index violation")
+	// ErrElementNotFound возвращается, если ни один элемент не подошёл под условие.
+	ErrElementNotFound = errors.New("значение не найдено")
+)
+
+// Пример использования
+// users := NewNewState[int64, *User]()
+// users.AddFieldIndex("Username", true)
+// users.AddIndex("city", func(u *User) any { return u.City }, false)
+// keys, err := users.Lookup("city", "Москва")
+
+// stateIndex - вторичный индекс NewState: значение поля -> множество ключей.
+// values хранит проиндексированное значение каждого ключа: при изменении элемента
+// по указателю extract от старого элемента уже вернёт новое значение.
+type stateIndex[K comparable, V any] struct {
+	extract func(element V) any
+	unique  bool
+	// field - индекс создан AddFieldIndex и хранит само значение поля,
+	// поэтому по нему можно искать вместо сравнения поля.
+	field   bool
+	entries map[any]map[K]struct{}
+	values  map[K]any
+}
+
+// AddIndex объявляет индекс name со значениями, которые возвращает extract.
+// Элементы, для которых extract вернул nil, в индекс не попадают.
+// Для unique-индекса каждое значение может принадлежать только одному ключу.
+func (ns *NewState[K, V]) AddIndex(name string, extract func(element V) any, unique bool) error {
+	return ns.addIndex(name, extract, unique, false)
+}
+
+func (ns *NewState[K, V]) addIndex(name string, extract func(element V) any, unique, field bool) error {
+	ns.Mu.Lock()
+	defer ns.Mu.Unlock()
+
+	if _, exists := ns.indexes[name]; exists {
+		return fmt.Errorf("%w: %s", ErrIndexExists, name)
+	}
+
+	index := &stateIndex[K, V]{
+		extract: extract,
+		unique:  unique,
+		field:   field,
+		entries: make(map[any]map[K]struct{}),
+		values:  make(map[K]any),
+	}
+	for key, element := range ns.State {
+		if owner, conflict := index.conflict(key, element); conflict {
+			return fmt.Errorf("index %s: %w: %v and %v", name, ErrUniqueViolation, key, owner)
+		}
+		index.add(key, element)
+	}
+
+	if ns.indexes == nil {
+		ns.indexes = make(map[string]*stateIndex[K, V])
+	}
+	ns.indexes[name] = index
+	return nil
+}
+
+// AddFieldIndex объявляет индекс по полю структуры V с именем field.
+// Индекс называется так же, как поле, и используется GetKeyByNameFieldAndVal.
+func (ns *NewState[K, V]) AddFieldIndex(field string, unique bool) error {
+	get, _, err := fieldGetter[V](field)
+	if err != nil {
+		return err
+	}
+
+	return ns.addIndex(field, func(element V) any {
+		value, ok := get(element)
+		if !ok {
+			return nil
+		}
+		return value
+	}, unique, true)
+}
+
+// fieldIndex возвращает индекс AddFieldIndex для поля field. Вызывается под ns.Mu.
+func (ns *NewState[K, V]) fieldIndex(field string) (*stateIndex[K, V], bool) {
+	index, ok := ns.indexes[field]
+	return index, ok && index.field
+}
+
+// Lookup возвращает ключи всех элементов, у которых индекс name равен value.
+// Порядок ключей не определён.
+func (ns *NewState[K, V]) Lookup(name string, value any) ([]K, error) {
+	ns.Mu.RLock()
+	defer ns.Mu.RUnlock()
+
+	index, ok := ns.indexes[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrIndexNotFound, name)
+	}
+
+	now := time.Now()
+	keys := make([]K, 0, len(index.entries[indexValue(value)]))
+	for key := range index.entries[indexValue(value)] {
+		if !ns.expired(key, now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// checkIndexes проверяет уникальные индексы перед записью element под key.
+// Просроченный владелец значения удаляется. Вызывается под ns.Mu.
+func (ns *NewState[K, V]) checkIndexes(key K, element V) error {
+	now := time.Now()
+	for name, index := range ns.indexes {
+		owner, conflict := index.conflict(key, element)
+		if conflict && ns.expired(owner, now) {
+			ns.evict(owner, EvictExpired)
+			owner, conflict = index.conflict(key, element)
+		}
+		if conflict {
+			return fmt.Errorf("index %s: %w: %v is already used by %v", name, ErrUniqueViolation, index.extract(element), owner)
+		}
+	}
+	return nil
+}
+
+// reindex заменяет записи индексов для key. Вызывается под ns.Mu.
+func (ns *NewState[K, V]) reindex(key K, element V) {
+	for _, index := range ns.indexes {
+		index.remove(key)
+		index.add(key, element)
+	}
+}
+
+// unindex удаляет записи индексов для key. Вызывается под ns.Mu.
+func (ns *NewState[K, V]) unindex(key K) {
+	for _, index := range ns.indexes {
+		index.remove(key)
+	}
+}
+
+// conflict возвращает другой ключ, уже владеющий значением уникального индекса.
+func (index *stateIndex[K, V]) conflict(key K, element V) (K, bool) {
+	var owner K
+	if !index.unique {
+		return owner, false
+	}
+	value := index.extract(element)
+	if value == nil {
+		return owner, false
+	}
+	for owner := range index.entries[indexValue(value)] {
+		if owner != key {
+			return owner, true
+		}
+	}
+	return owner, false
+}
+
+func (index *stateIndex[K, V]) add(key K, element V) {
+	value := index.extract(element)
+	if value == nil {
+		return
+	}
+	value = indexValue(value)
+	keys, ok := index.entries[value]
+	if !ok {
+		keys = make(map[K]struct{})
+		index.entries[value] = keys
+	}
+	keys[key] = struct{}{}
+	index.values[key] = value
+}
+
+func (index *stateIndex[K, V]) remove(key K) {
+	value, ok := index.values[key]
+	if !ok {
+		return
+	}
+	delete(index.values, key)
+	delete(index.entries[value], key)
+	if len(index.entries[value]) == 0 {
+		delete(index.entries, value)
+	}
+}
+
+// indexValue приводит значение к виду, пригодному для ключа map и сравнения через ==.
+// Несравнимые значения (срезы, map) заменяются их текстовым представлением.
+func indexValue(value any) any {
+	if value == nil || reflect.ValueOf(value).Comparable() {
+		return value
+	}
+	return indexRepr{fmt.Sprintf("%T %#v", value, value)}
+}
+
+type indexRepr struct {
+	repr string
+}
@@ -0,0 +1,58 @@
+package LCB
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type indexedUser struct {
+	Username string
+	Status   string
+}
+
+func TestIndexFollowsPointerMutation(t *testing.T) {
+	ns := NewNewState[int64, *indexedUser]()
+	if err := ns.AddFieldIndex("Username", true); err != nil {
+		t.Fatal(err)
+	}
+
+	user := &indexedUser{Username: "alice"}
+	ns.SetElement(1, user)
+	user.Username = "bob"
+	ns.SetElement(1, user)
+
+	if keys, _ := ns.Lookup("Username", "alice"); len(keys) != 0 {
+		t.Fatalf("stale index entry for alice: %v", keys)
+	}
+	if keys, _ := ns.Lookup("Username", "bob"); len(keys) != 1 || keys[0] != 1 {
+		t.Fatalf("Lookup(bob) = %v", keys)
+	}
+
+	ns.DeleteElement(1)
+	if err := ns.Put(2, &indexedUser{Username: "alice"}); err != nil {
+		t.Fatalf("Put after delete: %v", err)
+	}
+	if err := ns.Put(3, &indexedUser{Username: "alice"}); !errors.Is(err, ErrUniqueViolation) {
+		t.Fatalf("Put duplicate = %v, want ErrUniqueViolation", err)
+	}
+}
+
+// Индекс из AddIndex с именем поля хранит производное значение, и поиск по полю его не использует.
+func TestFieldLookupIgnoresCustomIndexWithFieldName(t *testing.T) {
+	ns := NewNewState[int64, indexedUser]()
+	ns.SetElement(1, indexedUser{Status: "Pending"})
+	err := ns.AddIndex("Status", func(u indexedUser) any {
+		return strings.ToLower(u.Status)
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key, err := ns.GetKeyByNameFieldAndVal("Status", "Pending"); err != nil || key != 1 {
+		t.Fatalf("GetKeyByNameFieldAndVal = %d, %v", key, err)
+	}
+	if keys, _ := ns.Lookup("Status", "pending"); len(keys) != 1 {
+		t.Fatalf("Lookup on custom index = %v", keys)
+	}
+}
@@ -0,0 +1,317 @@
+package LCB
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxCallbackDataLen - максимальная длина callback_data в байтах.
+const MaxCallbackDataLen = 64
+
+var (
+	// ErrCallbackDataTooLong возвращается, если callback_data длиннее MaxCallbackDataLen байт.
+	ErrCallbackDataTooLong = errors.New("callback data exceeds 64 bytes")
+	// ErrEmptyButtonText возвращается для кнопки без текста.
+	ErrEmptyButtonText = errors.New("button text is empty")
+	// ErrButtonAction возвращается, если у inline-кнопки задано не ровно одно действие.
+	ErrButtonAction = errors.New("inline button must have exactly one action")
+	// ErrButtonRequest возвращается, если у reply-кнопки задано несколько запросов.
+	ErrButtonRequest = errors.New("reply button must have at most one request")
+)
+
+// Пример использования
+// markup, err := NewInlineKeyboard().Width(2).
+// 	Add(InlineCallback("Да", "yes"), InlineCallback("Нет", "no")).
+// 	Row(InlineURL("Сайт", "https://example.com")).
+// 	Build()
+
+// InlineKeyboardBuilder собирает InlineKeyboardMarkup по строкам.
+type InlineKeyboardBuilder struct {
+	rows  [][]InlineKeyboardButton
+	width int
+}
+
+func NewInlineKeyboard() *InlineKeyboardBuilder {
+	return &InlineKeyboardBuilder{}
+}
+
+// Width задаёт максимальное число кнопок в строке для Add. 0 - без ограничения.
+func (k *InlineKeyboardBuilder) Width(n int) *InlineKeyboardBuilder {
+	k.width = n
+	return k
+}
+
+// Row добавляет новую строку из переданных кнопок без переноса.
+func (k *InlineKeyboardBuilder) Row(buttons ...InlineKeyboardButton) *InlineKeyboardBuilder {
+	k.rows = append(k.rows, buttons)
+	return k
+}
+
+// Column добавляет каждую кнопку отдельной строкой.
+func (k *InlineKeyboardBuilder) Column(buttons ...InlineKeyboardButton) *InlineKeyboardBuilder {
+	for _, button := range buttons {
+		k.rows = append(k.rows, []InlineKeyboardButton{button})
+	}
+	return k
+}
+
+// Add дописывает кнопки в последнюю строку, перенося их на новую строку по Width.
+func (k *InlineKeyboardBuilder) Add(buttons ...InlineKeyboardButton) *InlineKeyboardBuilder {
+	k.rows = appendWrapped(k.rows, k.width, buttons)
+	return k
+}
+
+// Build проверяет кнопки и возвращает готовую разметку.
+func (k *InlineKeyboardBuilder) Build() (*InlineKeyboardMarkup, error) {
+	rows := make([][]InlineKeyboardButton, 0, len(k.rows))
+	for i, row := range k.rows {
+		if len(row) == 0 {
+			continue
+		}
+		for j, button := range row {
+			if err := button.Validate(); err != nil {
+				return nil, fmt.Errorf("row %d, button %d: %w", i, j, err)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return &InlineKeyboardMarkup{InlineKeyboard: rows}, nil
+}
+
+// Validate проверяет, что кнопку примет Telegram.
+func (b InlineKeyboardButton) Validate() error {
+	if b.Text == "" {
+		return ErrEmptyButtonText
+	}
+
+	actions := 0
+	if b.URL != "" {
+		actions++
+	}
+	if b.CallbackData != "" {
+		actions++
+		if len(b.CallbackData) > MaxCallbackDataLen {
+			return fmt.Errorf("%w: %q is %d bytes", ErrCallbackDataTooLong, b.CallbackData, len(b.CallbackData))
+		}
+	}
+	if b.WebApp != nil {
+		actions++
+	}
+	if b.LoginURL != nil {
+		actions++
+	}
+	if b.SwitchInlineQuery != nil {
+		actions++
+	}
+	if b.SwitchInlineQueryCurrentChat != nil {
+		actions++
+	}
+	if b.CopyText != nil {
+		actions++
+	}
+	if b.Pay {
+		actions++
+	}
+	if actions != 1 {
+		return ErrButtonAction
+	}
+	return nil
+}
+
+func InlineCallback(text, data string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, CallbackData: data}
+}
+
+func InlineURL(text, url string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, URL: url}
+}
+
+func InlineWebApp(text, url string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, WebApp: &WebAppInfo{URL: url}}
+}
+
+// InlineSwitch предлагает выбрать чат и вставляет в поле ввода "@bot query".
+func InlineSwitch(text, query string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, SwitchInlineQuery: &query}
+}
+
+// InlineSwitchCurrentChat вставляет "@bot query" в поле ввода текущего чата.
+func InlineSwitchCurrentChat(text, query string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, SwitchInlineQueryCurrentChat: &query}
+}
+
+func InlineLogin(text string, login LoginURL) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, LoginURL: &login}
+}
+
+// InlinePay создаёт кнопку оплаты. Она должна быть первой в первой строке клавиатуры счёта.
+func InlinePay(text string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, Pay: true}
+}
+
+func InlineCopyText(text, copyText string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, CopyText: &CopyTextButton{Text: copyText}}
+}
+
+// ReplyKeyboardBuilder собирает ReplyKeyboardMarkup по строкам.
+type ReplyKeyboardBuilder struct {
+	markup ReplyKeyboardMarkup
+	width  int
+}
+
+func NewReplyKeyboard() *ReplyKeyboardBuilder {
+	return &ReplyKeyboardBuilder{}
+}
+
+// Width задаёт максимальное число кнопок в строке для Add. 0 - без ограничения.
+func (k *ReplyKeyboardBuilder) Width(n int) *ReplyKeyboardBuilder {
+	k.width = n
+	return k
+}
+
+// Row добавляет новую строку из переданных кнопок без переноса.
+func (k *ReplyKeyboardBuilder) Row(buttons ...ReplyKeyboardButton) *ReplyKeyboardBuilder {
+	k.markup.ReplyKeyboard = append(k.markup.ReplyKeyboard, buttons)
+	return k
+}
+
+// Column добавляет каждую кнопку отдельной строкой.
+func (k *ReplyKeyboardBuilder) Column(buttons ...ReplyKeyboardButton) *ReplyKeyboardBuilder {
+	for _, button := range buttons {
+		k.markup.ReplyKeyboard = append(k.markup.ReplyKeyboard, []ReplyKeyboardButton{button})
+	}
+	return k
+}
+
+// Add дописывает кнопки в последнюю строку, перенося их на новую строку по Width.
+func (k *ReplyKeyboardBuilder) Add(buttons ...ReplyKeyboardButton) *ReplyKeyboardBuilder {
+	k.markup.ReplyKeyboard = appendWrapped(k.markup.ReplyKeyboard, k.width, buttons)
+	return k
+}
+
+// Resize просит клиент подогнать высоту клавиатуры под кнопки.
+func (k *ReplyKeyboardBuilder) Resize() *ReplyKeyboardBuilder {
+	k.markup.ResizeKeyboard = true
+	return k
+}
+
+// OneTime скрывает клавиатуру после первого нажатия.
+func (k *ReplyKeyboardBuilder) OneTime() *ReplyKeyboardBuilder {
+	k.markup.OneTimeKeyboard = true
+	return k
+}
+
+// Persistent не даёт клиенту скрыть клавиатуру.
+func (k *ReplyKeyboardBuilder) Persistent() *ReplyKeyboardBuilder {
+	k.markup.IsPersistent = true
+	return k
+}
+
+// Selective показывает клавиатуру только упомянутым пользователям и автору сообщения, на которое отвечает бот.
+func (k *ReplyKeyboardBuilder) Selective() *ReplyKeyboardBuilder {
+	k.markup.Selective = true
+	return k
+}
+
+// Placeholder задаёт подсказку в поле ввода, пока клавиатура активна (до 64 символов).
+func (k *ReplyKeyboardBuilder) Placeholder(text string) *ReplyKeyboardBuilder {
+	k.markup.InputFieldPlaceholder = text
+	return k
+}
+
+// Build проверяет кнопки и возвращает готовую разметку.
+func (k *ReplyKeyboardBuilder) Build() (*ReplyKeyboardMarkup, error) {
+	markup := k.markup
+	markup.ReplyKeyboard = make([][]ReplyKeyboardButton, 0, len(k.markup.ReplyKeyboard))
+	for i, row := range k.markup.ReplyKeyboard {
+		if len(row) == 0 {
+			continue
+		}
+		for j, button := range row {
+			if err := button.Validate(); err != nil {
+				return nil, fmt.Errorf("row %d, button %d: %w", i, j, err)
+			}
+		}
+		markup.ReplyKeyboard = append(markup.ReplyKeyboard, row)
+	}
+	return &markup, nil
+}
+
+// Validate проверяет, что кнопку примет Telegram.
+func (b ReplyKeyboardButton) Validate() error {
+	if b.Text == "" {
+		return ErrEmptyButtonText
+	}
+
+	requests := 0
+	if b.RequestUsers != nil {
+		requests++
+	}
+	if b.RequestChat != nil {
+		requests++
+	}
+	if b.RequestContact {
+		requests++
+	}
+	if b.RequestLocation {
+		requests++
+	}
+	if b.RequestPoll != nil {
+		requests++
+	}
+	if b.WebApp != nil {
+		requests++
+	}
+	if requests > 1 {
+		return ErrButtonRequest
+	}
+	return nil
+}
+
+func ReplyButton(text string) ReplyKeyboardButton {
+	return ReplyKeyboardButton{Text: text}
+}
+
+// ReplyContact запрашивает номер телефона пользователя, ответ приходит в Message.Contact.
+// Работает только в личных чатах.
+func ReplyContact(text string) ReplyKeyboardButton {
+	return ReplyKeyboardButton{Text: text, RequestContact: true}
+}
+
+// ReplyLocation запрашивает геолокацию пользователя, ответ приходит в Message.Location.
+// Работает только в личных чатах.
+func ReplyLocation(text string) ReplyKeyboardButton {
+	return ReplyKeyboardButton{Text: text, RequestLocation: true}
+}
+
+// ReplyPoll предлагает создать опрос. pollType - "quiz", "regular" или пусто.
+func ReplyPoll(text, pollType string) ReplyKeyboardButton {
+	return ReplyKeyboardButton{Text: text, RequestPoll: &KeyboardButtonPollType{Type: pollType}}
+}
+
+// ReplyUsers предлагает выбрать пользователей, ответ приходит в Message.UsersShared.
+func ReplyUsers(text string, request KeyboardButtonRequestUsers) ReplyKeyboardButton {
+	return ReplyKeyboardButton{Text: text, RequestUsers: &request}
+}
+
+// ReplyChat предлагает выбрать чат, ответ приходит в Message.ChatShared.
+func ReplyChat(text string, request KeyboardButtonRequestChat) ReplyKeyboardButton {
+	return ReplyKeyboardButton{Text: text, RequestChat: &request}
+}
+
+func ReplyWebApp(text, url string) ReplyKeyboardButton {
+	return ReplyKeyboardButton{Text: text, WebApp: &WebAppInfo{URL: url}}
+}
+
+// appendWrapped дописывает кнопки в последнюю строку, начиная новую, когда в строке width кнопок.
+func appendWrapped[T any](rows [][]T, width int, buttons []T) [][]T {
+	for _, button := range buttons {
+		last := len(rows) - 1
+		if last < 0 || (width > 0 && len(rows[last]) >= width) {
+			rows = append(rows, nil)
+			last++
+		}
+		rows[last] = append(rows[last], button)
+	}
+	return rows
+}
@@ -0,0 +1,86 @@
+package LCB
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrConflictingMarkup возвращается, если в Utils задано больше одной разметки.
+var ErrConflictingMarkup = errors.New("conflicting reply markup: only one of Inline, Reply, Delete, ForceReply, Markup may be set")
+
+// ErrInlineMarkupOnly возвращается, если метод Telegram принимает только inline-клавиатуру.
+var ErrInlineMarkupOnly = errors.New("only inline keyboard markup is allowed here")
+
+func (*InlineKeyboardMarkup) isReplyMarkup() {}
+func (*ReplyKeyboardMarkup) isReplyMarkup()  {}
+func (*DeleteKeyboard) isReplyMarkup()       {}
+func (*ForceReply) isReplyMarkup()           {}
+
+func (d DeleteKeyboard) MarshalJSON() ([]byte, error) {
+	type deleteKeyboard DeleteKeyboard
+	d.RemoveKeyboard = true
+	return json.Marshal(deleteKeyboard(d))
+}
+
+func (f ForceReply) MarshalJSON() ([]byte, error) {
+	type forceReply ForceReply
+	f.ForceReply = true
+	return json.Marshal(forceReply(f))
+}
+
+// replyMarkup возвращает единственную заданную в Utils разметку или nil.
+func (u Utils) replyMarkup() (ReplyMarkup, error) {
+	var found []ReplyMarkup
+
+	if u.Inline != nil {
+		found = append(found, u.Inline)
+	}
+	if u.Reply != nil {
+		found = append(found, u.Reply)
+	}
+	if u.Delete != nil {
+		found = append(found, u.Delete)
+	}
+	if u.ForceReply != nil {
+		found = append(found, u.ForceReply)
+	}
+	if u.Markup != nil && !isNilMarkup(u.Markup) {
+		found = append(found, u.Markup)
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, nil
+	case 1:
+		return found[0], nil
+	default:
+		return nil, ErrConflictingMarkup
+	}
+}
+
+// inlineMarkup возвращает разметку для методов edit*, которые принимают только inline-клавиатуру.
+func (u Utils) inlineMarkup() (*InlineKeyboardMarkup, error) {
+	markup, err := u.replyMarkup()
+	if err != nil || markup == nil {
+		return nil, err
+	}
+	inline, ok := markup.(*InlineKeyboardMarkup)
+	if !ok {
+		return nil, ErrInlineMarkupOnly
+	}
+	return inline, nil
+}
+
+func isNilMarkup(markup ReplyMarkup) bool {
+	switch m := markup.(type) {
+	case *InlineKeyboardMarkup:
+		return m == nil
+	case *ReplyKeyboardMarkup:
+		return m == nil
+	case *DeleteKeyboard:
+		return m == nil
+	case *ForceReply:
+		return m == nil
+	}
+	return false
+}
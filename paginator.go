@@ -0,0 +1,127 @@
+package LCB
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+const paginatorPrefix = "pg:"
+
+// Пример использования
+// pager := bot.NewPaginator("orders", func(chatID int64) []InlineKeyboardButton {
+// 	return orderButtons(chatID)
+// })
+// pager.PerPage = 5
+// pager.Send(chatID, 0)
+
+// Paginator выводит список кнопок постранично и сам обрабатывает нажатия "◀" и "▶",
+// редактируя сообщение на месте.
+type Paginator struct {
+	bot *Bot
+	id  string
+
+	// PerPage - число элементов на странице.
+	PerPage int
+	// Columns - число кнопок в строке.
+	Columns int
+	// Items возвращает полный список элементов для чата.
+	Items func(chatID int64) []InlineKeyboardButton
+	// Text возвращает текст сообщения для страницы (нумерация с 0).
+	Text func(chatID int64, page, pages int) string
+}
+
+// NewPaginator создаёт пагинатор и регистрирует обработчик его кнопок навигации.
+// id попадает в callback_data и должен быть коротким и уникальным среди пагинаторов бота.
+func (b *Bot) NewPaginator(id string, items func(chatID int64) []InlineKeyboardButton) *Paginator {
+	p := &Paginator{
+		bot:     b,
+		id:      id,
+		PerPage: 10,
+		Columns: 1,
+		Items:   items,
+		Text: func(chatID int64, page, pages int) string {
+			return fmt.Sprintf("Страница %d из %d", page+1, pages)
+		},
+	}
+	b.handleWidget(p.isNavigation, func(c *Ctx) { p.handle(c.Update) })
+	return p
+}
+
+// Render возвращает текст и клавиатуру страницы page. Номер страницы приводится к допустимому диапазону.
+func (p *Paginator) Render(chatID int64, page int) (string, *InlineKeyboardMarkup) {
+	items := p.Items(chatID)
+	perPage := p.PerPage
+	if perPage <= 0 {
+		perPage = len(items)
+	}
+
+	pages := 1
+	if perPage > 0 && len(items) > 0 {
+		pages = (len(items) + perPage - 1) / perPage
+	}
+	page = max(0, min(page, pages-1))
+
+	start := min(page*perPage, len(items))
+	end := min(start+perPage, len(items))
+
+	keyboard := NewInlineKeyboard().Width(p.Columns).Add(items[start:end]...)
+	if pages > 1 {
+		var nav []InlineKeyboardButton
+		if page > 0 {
+			nav = append(nav, InlineCallback("◀", p.data(page-1)))
+		}
+		nav = append(nav, InlineCallback(fmt.Sprintf("%d/%d", page+1, pages), p.data(page)))
+		if page < pages-1 {
+			nav = append(nav, InlineCallback("▶", p.data(page+1)))
+		}
+		keyboard.Row(nav...)
+	}
+
+	markup, err := keyboard.Build()
+	if err != nil {
+		log.Println("Error building paginator keyboard:", err)
+	}
+	return p.Text(chatID, page, pages), markup
+}
+
+// Send отправляет страницу page новым сообщением и возвращает его ID.
+func (p *Paginator) Send(chatID int64, page int) int {
+	text, markup := p.Render(chatID, page)
+	return p.bot.SendMessage(chatID, text, Utils{Inline: markup})
+}
+
+func (p *Paginator) data(page int) string {
+	return paginatorPrefix + p.id + ":" + strconv.Itoa(page)
+}
+
+func (p *Paginator) isNavigation(update Update) bool {
+	if update.CallbackQuery == nil || update.CallbackQuery.Message == nil {
+		return false
+	}
+	_, ok := p.parse(update.CallbackQuery.Data)
+	return ok
+}
+
+func (p *Paginator) parse(data string) (int, bool) {
+	rest, ok := strings.CutPrefix(data, paginatorPrefix+p.id+":")
+	if !ok {
+		return 0, false
+	}
+	page, err := strconv.Atoi(rest)
+	if err != nil {
+		return 0, false
+	}
+	return page, true
+}
+
+func (p *Paginator) handle(update Update) {
+	query := update.CallbackQuery
+	defer p.bot.AnswerCallbackQuery(query.ID, "", "false")
+
+	page, _ := p.parse(query.Data)
+	chatID := query.Message.Chat.ID
+	text, markup := p.Render(chatID, page)
+	p.bot.EditMessage(chatID, query.Message.MessageID, text, Utils{Inline: markup})
+}
@@ -0,0 +1,404 @@
+package LCB
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrQueryCompare возвращается, если значения в условии или сортировке нельзя сравнить.
+var ErrQueryCompare = errors.New("query: values are not comparable")
+
+// QueryOp - оператор условия Where.
+type QueryOp int
+
+const (
+	OpEq QueryOp = iota
+	OpNe
+	OpLt
+	OpGt
+	// OpIn - поле равно одному из элементов среза value.
+	OpIn
+	// OpContains - строка содержит подстроку, срез содержит элемент или map содержит ключ.
+	OpContains
+)
+
+// Entry - пара ключ/значение из результата запроса.
+type Entry[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// Пример использования
+// orders, err := state.Query().
+// 	Where("Status", OpEq, "pending").
+// 	Where("Created", OpLt, deadline).
+// 	OrderBy("Created", false).
+// 	Offset(10).Limit(10).
+// 	All()
+
+// Query описывает выборку из NewState. Элементы копируются под блокировкой одним
+// снимком, а условия, предикаты и сортировка выполняются уже без блокировки.
+type Query[K comparable, V any] struct {
+	state      *NewState[K, V]
+	conditions []queryCondition[V]
+	predicates []func(key K, element V) bool
+	orders     []queryOrder[K, V]
+	offset     int
+	limit      int
+	err        error
+}
+
+type queryCondition[V any] struct {
+	field string
+	typ   reflect.Type
+	get   func(element V) (any, bool)
+	op    QueryOp
+	value any
+}
+
+type queryOrder[K comparable, V any] struct {
+	less func(a, b Entry[K, V]) (bool, error)
+}
+
+// Query начинает новую выборку.
+func (ns *NewState[K, V]) Query() *Query[K, V] {
+	return &Query[K, V]{state: ns}
+}
+
+// Filter добавляет предикат. Предикат вызывается без блокировки состояния.
+func (q *Query[K, V]) Filter(predicate func(key K, element V) bool) *Query[K, V] {
+	q.predicates = append(q.predicates, predicate)
+	return q
+}
+
+// Where добавляет условие на поле структуры V.
+func (q *Query[K, V]) Where(field string, op QueryOp, value any) *Query[K, V] {
+	get, typ, err := fieldGetter[V](field)
+	if err != nil {
+		q.setErr(err)
+		return q
+	}
+	if op == OpIn {
+		if value == nil {
+			q.setErr(errors.New("query: OpIn expects a slice, got nil"))
+			return q
+		}
+		if kind := reflect.TypeOf(value).Kind(); kind != reflect.Slice && kind != reflect.Array {
+			q.setErr(fmt.Errorf("query: OpIn expects a slice, got %T", value))
+			return q
+		}
+	}
+	q.conditions = append(q.conditions, queryCondition[V]{field: field, typ: typ, get: get, op: op, value: value})
+	return q
+}
+
+// OrderBy сортирует по полю структуры V. Несколько вызовов задают сортировку
+// по нескольким полям. Элементы без поля (nil-указатели) идут в конце.
+func (q *Query[K, V]) OrderBy(field string, desc bool) *Query[K, V] {
+	get, _, err := fieldGetter[V](field)
+	if err != nil {
+		q.setErr(err)
+		return q
+	}
+	q.orders = append(q.orders, queryOrder[K, V]{less: func(a, b Entry[K, V]) (bool, error) {
+		av, aok := get(a.Value)
+		bv, bok := get(b.Value)
+		if !aok || !bok {
+			return aok && !bok, nil
+		}
+		c, err := compareValues(av, bv)
+		if desc {
+			c = -c
+		}
+		return c < 0, err
+	}})
+	return q
+}
+
+// SortFunc сортирует произвольной функцией сравнения.
+func (q *Query[K, V]) SortFunc(less func(a, b Entry[K, V]) bool) *Query[K, V] {
+	q.orders = append(q.orders, queryOrder[K, V]{less: func(a, b Entry[K, V]) (bool, error) {
+		return less(a, b), nil
+	}})
+	return q
+}
+
+// Offset пропускает первые n результатов.
+func (q *Query[K, V]) Offset(n int) *Query[K, V] {
+	q.offset = n
+	return q
+}
+
+// Limit ограничивает число результатов, 0 - без ограничения.
+func (q *Query[K, V]) Limit(n int) *Query[K, V] {
+	q.limit = n
+	return q
+}
+
+// All выполняет запрос.
+func (q *Query[K, V]) All() ([]Entry[K, V], error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+
+	var matched []Entry[K, V]
+	for _, entry := range q.snapshot() {
+		ok, err := q.match(entry)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, entry)
+		}
+	}
+
+	if len(q.orders) > 0 {
+		var sortErr error
+		sort.SliceStable(matched, func(i, j int) bool {
+			for _, order := range q.orders {
+				less, err := order.less(matched[i], matched[j])
+				if err != nil && sortErr == nil {
+					sortErr = err
+				}
+				if less {
+					return true
+				}
+				if greater, _ := order.less(matched[j], matched[i]); greater {
+					return false
+				}
+			}
+			return false
+		})
+		if sortErr != nil {
+			return nil, sortErr
+		}
+	}
+
+	if q.offset >= len(matched) {
+		return []Entry[K, V]{}, nil
+	}
+	matched = matched[q.offset:]
+	if q.limit > 0 && q.limit < len(matched) {
+		matched = matched[:q.limit]
+	}
+	return matched, nil
+}
+
+// Count возвращает число подходящих элементов без учёта Offset и Limit.
+func (q *Query[K, V]) Count() (int, error) {
+	offset, limit := q.offset, q.limit
+	q.offset, q.limit = 0, 0
+	entries, err := q.All()
+	q.offset, q.limit = offset, limit
+	return len(entries), err
+}
+
+// snapshot копирует элементы под RLock. Если для условия OpEq есть индекс
+// AddFieldIndex и значение того же типа, что поле, копируются только элементы из индекса.
+func (q *Query[K, V]) snapshot() []Entry[K, V] {
+	ns := q.state
+	ns.Mu.RLock()
+	defer ns.Mu.RUnlock()
+
+	now := time.Now()
+	for _, condition := range q.conditions {
+		if condition.op != OpEq || reflect.TypeOf(condition.value) != condition.typ {
+			continue
+		}
+		index, ok := ns.fieldIndex(condition.field)
+		if !ok {
+			continue
+		}
+		keys := index.entries[indexValue(condition.value)]
+		entries := make([]Entry[K, V], 0, len(keys))
+		for key := range keys {
+			if ns.expired(key, now) {
+				continue
+			}
+			entries = append(entries, Entry[K, V]{Key: key, Value: ns.State[key]})
+		}
+		return entries
+	}
+
+	entries := make([]Entry[K, V], 0, len(ns.State))
+	for key, element := range ns.State {
+		if ns.expired(key, now) {
+			continue
+		}
+		entries = append(entries, Entry[K, V]{Key: key, Value: element})
+	}
+	return entries
+}
+
+func (q *Query[K, V]) match(entry Entry[K, V]) (bool, error) {
+	for _, condition := range q.conditions {
+		value, ok := condition.get(entry.Value)
+		if !ok {
+			return false, nil
+		}
+		ok, err := condition.match(value)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	for _, predicate := range q.predicates {
+		if !predicate(entry.Key, entry.Value) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (q *Query[K, V]) setErr(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+func (c queryCondition[V]) match(value any) (bool, error) {
+	switch c.op {
+	case OpEq:
+		return equalValues(value, c.value), nil
+	case OpNe:
+		return !equalValues(value, c.value), nil
+	case OpLt, OpGt:
+		cmp, err := compareValues(value, c.value)
+		if err != nil {
+			return false, fmt.Errorf("field %s: %w", c.field, err)
+		}
+		if c.op == OpLt {
+			return cmp < 0, nil
+		}
+		return cmp > 0, nil
+	case OpIn:
+		list := reflect.ValueOf(c.value)
+		for i := 0; i < list.Len(); i++ {
+			if equalValues(value, list.Index(i).Interface()) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case OpContains:
+		return containsValue(value, c.value)
+	}
+	return false, fmt.Errorf("query: unknown operator %d", c.op)
+}
+
+// fieldGetter проверяет, что у V есть поле field, и возвращает функцию чтения поля
+// и его тип. Для nil-указателя функция возвращает false.
+func fieldGetter[V any](field string) (func(element V) (any, bool), reflect.Type, error) {
+	t := reflect.TypeOf((*V)(nil)).Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("ожидалась структура, но получен %s", t.Kind())
+	}
+	structField, ok := t.FieldByName(field)
+	if !ok {
+		return nil, nil, fmt.Errorf("поле %s не найдено", field)
+	}
+
+	return func(element V) (any, bool) {
+		v := reflect.ValueOf(element)
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return nil, false
+			}
+			v = v.Elem()
+		}
+		return v.FieldByName(field).Interface(), true
+	}, structField.Type, nil
+}
+
+func equalValues(a, b any) bool {
+	if c, err := compareValues(a, b); err == nil {
+		return c == 0
+	}
+	return indexValue(a) == indexValue(b)
+}
+
+// compareValues сравнивает числа любых типов между собой, строки и time.Time.
+func compareValues(a, b any) (int, error) {
+	if at, ok := a.(time.Time); ok {
+		if bt, ok := b.(time.Time); ok {
+			return at.Compare(bt), nil
+		}
+	}
+
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch {
+	case isInt(av) && isInt(bv):
+		return cmpOrdered(av.Int(), bv.Int()), nil
+	case isUint(av) && isUint(bv):
+		return cmpOrdered(av.Uint(), bv.Uint()), nil
+	case isNumber(av) && isNumber(bv):
+		return cmpOrdered(toFloat(av), toFloat(bv)), nil
+	case av.Kind() == reflect.String && bv.Kind() == reflect.String:
+		return strings.Compare(av.String(), bv.String()), nil
+	}
+	return 0, fmt.Errorf("%w: %T and %T", ErrQueryCompare, a, b)
+}
+
+func containsValue(container, value any) (bool, error) {
+	v := reflect.ValueOf(container)
+	switch v.Kind() {
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return false, fmt.Errorf("%w: %T in string", ErrQueryCompare, value)
+		}
+		return strings.Contains(v.String(), s), nil
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if equalValues(v.Index(i).Interface(), value) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			if equalValues(key.Interface(), value) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("%w: contains on %T", ErrQueryCompare, container)
+}
+
+func isInt(v reflect.Value) bool {
+	return v.IsValid() && v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64
+}
+
+func isUint(v reflect.Value) bool {
+	return v.IsValid() && v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uintptr
+}
+
+func isNumber(v reflect.Value) bool {
+	return isInt(v) || isUint(v) || v.IsValid() && (v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64)
+}
+
+func toFloat(v reflect.Value) float64 {
+	switch {
+	case isInt(v):
+		return float64(v.Int())
+	case isUint(v):
+		return float64(v.Uint())
+	}
+	return v.Float()
+}
+
+func cmpOrdered[T int64 | uint64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,44 @@
+package LCB
+
+import (
+	"strings"
+	"testing"
+)
+
+type testOrder struct {
+	Status string
+	Sum    int
+}
+
+func TestQueryOpInRejectsNonSlice(t *testing.T) {
+	ns := NewNewState[int64, testOrder]()
+	ns.SetElement(1, testOrder{Status: "pending", Sum: 10})
+
+	for _, value := range []any{nil, "pending", 10} {
+		if _, err := ns.Query().Where("Status", OpIn, value).All(); err == nil {
+			t.Errorf("Where(OpIn, %#v) was accepted", value)
+		}
+	}
+
+	entries, err := ns.Query().Where("Status", OpIn, []string{"done", "pending"}).All()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("All() = %v, %v, want one entry", entries, err)
+	}
+}
+
+func TestQueryIgnoresCustomIndexWithFieldName(t *testing.T) {
+	ns := NewNewState[int64, testOrder]()
+	ns.SetElement(1, testOrder{Status: "Pending"})
+	ns.AddIndex("Status", func(o testOrder) any {
+		return strings.ToLower(o.Status)
+	}, false)
+
+	if n, err := ns.Query().Where("Status", OpEq, "Pending").Count(); err != nil || n != 1 {
+		t.Fatalf("Count() = %d, %v, want 1", n, err)
+	}
+
+	ns.AddFieldIndex("Sum", false)
+	if n, err := ns.Query().Where("Sum", OpEq, 0).Count(); err != nil || n != 1 {
+		t.Fatalf("Count() by field index = %d, %v, want 1", n, err)
+	}
+}
@@ -0,0 +1,88 @@
+package LCB
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+)
+
+// PanicError передаётся в обработчик ошибок, если обработчик обновления запаниковал.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("handler panic: %v", e.Value)
+}
+
+// ErrorHandler получает ошибки и паники обработчиков вместе с контекстом обновления,
+// на котором они произошли, например чтобы ответить пользователю через c.Reply.
+type ErrorHandler func(c *Ctx, err error)
+
+// Пример использования
+// bot.OnError(func(c *Ctx, err error) {
+// 	var panicErr *PanicError
+// 	if errors.As(err, &panicErr) {
+// 		log.Printf("%v\n%s", panicErr.Value, panicErr.Stack)
+// 	}
+// 	bot.SendMessage(adminChatID, "Ошибка: "+err.Error(), Utils{})
+// 	c.Reply("Что-то пошло не так, попробуйте позже")
+// })
+
+// OnError задаёт обработчик ошибок и паник. По умолчанию они пишутся в лог.
+func (b *Bot) OnError(handler ErrorHandler) {
+	b.handlersMu.Lock()
+	b.errorHandler = handler
+	b.handlersMu.Unlock()
+}
+
+// call вызывает fn и превращает панику в *PanicError.
+func (b *Bot) call(c *Ctx, fn HandlerFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn(c)
+}
+
+// matches вызывает фильтр обработчика. Паника в фильтре, например обращение к
+// update.Message у нажатия кнопки, передаётся в handleError, а фильтр считается не подошедшим.
+func (b *Bot) matches(c *Ctx, filter func(update Update) bool) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			ok = false
+			b.handleError(c, &PanicError{Value: r, Stack: debug.Stack()})
+		}
+	}()
+	return filter(c.Update)
+}
+
+// handleError передаёт ошибку обработчику ошибок, не давая его собственной панике уронить бота.
+func (b *Bot) handleError(c *Ctx, err error) {
+	b.handlersMu.RLock()
+	handler := b.errorHandler
+	b.handlersMu.RUnlock()
+
+	if handler == nil {
+		logError(err)
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Error handler panic: %v\n%s", r, debug.Stack())
+			logError(err)
+		}
+	}()
+	handler(c, err)
+}
+
+func logError(err error) {
+	if panicErr, ok := err.(*PanicError); ok {
+		log.Printf("Handler panic: %v\n%s", panicErr.Value, panicErr.Stack)
+		return
+	}
+	log.Println("Handler error:", err)
+}
@@ -0,0 +1,67 @@
+package LCB
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFilterPanicIsRecovered(t *testing.T) {
+	b := NewBot("", false)
+
+	var mu sync.Mutex
+	var handled []error
+	b.OnError(func(c *Ctx, err error) {
+		mu.Lock()
+		handled = append(handled, err)
+		mu.Unlock()
+	})
+
+	ran := make(chan struct{}, 1)
+	b.Handle(func(update Update) bool {
+		return update.Message.Text == "/start"
+	}, func(c *Ctx) error {
+		return nil
+	})
+	b.Group().Handle(func(update Update) bool {
+		return update.CallbackQuery != nil
+	}, func(c *Ctx) error {
+		ran <- struct{}{}
+		return nil
+	})
+
+	b.dispatch(Update{CallbackQuery: &CallbackQuery{Data: "x"}})
+	b.running.Wait()
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("handler of the next group did not run after a filter panic")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var panicErr *PanicError
+	if len(handled) != 1 || !errors.As(handled[0], &panicErr) {
+		t.Fatalf("error handler got %v, want one *PanicError", handled)
+	}
+}
+
+func TestWaiterFilterPanicIsRecovered(t *testing.T) {
+	b := NewBot("", false)
+	b.OnError(func(c *Ctx, err error) {})
+
+	key := FSMKey{ChatID: 1, UserID: 1}
+	b.addWaiter(key, func(update Update) bool {
+		return update.Message.Text != ""
+	})
+
+	update := Update{CallbackQuery: &CallbackQuery{
+		From:    &User{ID: 1},
+		Message: &Message{Chat: &Chat{ID: 1}},
+	}}
+	if b.deliverToWaiter(update) {
+		t.Fatal("update was delivered to a waiter whose filter panicked")
+	}
+}
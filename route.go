@@ -0,0 +1,154 @@
+package LCB
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Пример использования
+// bot.CallbackRoute("order:{id:int}:cancel", func(c *Ctx, params RouteParams) error {
+// 	cancelOrder(params.Int("id"))
+// 	c.Edit("Заказ отменён")
+// 	return nil
+// })
+
+// RouteParams содержит параметры, извлечённые из callback_data по шаблону.
+// Значения уже приведены к типу из шаблона: int64, float64, bool или string.
+type RouteParams map[string]any
+
+func (p RouteParams) String(name string) string {
+	value, _ := p[name].(string)
+	return value
+}
+
+func (p RouteParams) Int(name string) int64 {
+	value, _ := p[name].(int64)
+	return value
+}
+
+func (p RouteParams) Float(name string) float64 {
+	value, _ := p[name].(float64)
+	return value
+}
+
+func (p RouteParams) Bool(name string) bool {
+	value, _ := p[name].(bool)
+	return value
+}
+
+type routeParam struct {
+	name string
+	kind string
+}
+
+// callbackRoute - скомпилированный шаблон вида "order:{id:int}:cancel".
+type callbackRoute struct {
+	re     *regexp.Regexp
+	params []routeParam
+}
+
+var routeParamPatterns = map[string]string{
+	"string": `(.+?)`,
+	"int":    `(-?\d+)`,
+	"float":  `(-?\d+(?:\.\d+)?)`,
+	"bool":   `(true|false)`,
+}
+
+func compileRoute(pattern string) (*callbackRoute, error) {
+	route := &callbackRoute{}
+	var expr strings.Builder
+	expr.WriteString("^")
+
+	rest := pattern
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			expr.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("route %q: unclosed parameter", pattern)
+		}
+		end += start
+
+		expr.WriteString(regexp.QuoteMeta(rest[:start]))
+		name, kind, found := strings.Cut(rest[start+1:end], ":")
+		if !found {
+			kind = "string"
+		}
+		paramExpr, ok := routeParamPatterns[kind]
+		if name == "" || !ok {
+			return nil, fmt.Errorf("route %q: invalid parameter %q", pattern, rest[start:end+1])
+		}
+		expr.WriteString(paramExpr)
+		route.params = append(route.params, routeParam{name: name, kind: kind})
+		rest = rest[end+1:]
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("route %q: %w", pattern, err)
+	}
+	route.re = re
+	return route, nil
+}
+
+// match возвращает параметры, если data подходит под шаблон.
+func (r *callbackRoute) match(data string) (RouteParams, bool) {
+	groups := r.re.FindStringSubmatch(data)
+	if groups == nil {
+		return nil, false
+	}
+
+	params := make(RouteParams, len(r.params))
+	for i, param := range r.params {
+		raw := groups[i+1]
+		switch param.kind {
+		case "int":
+			value, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return nil, false
+			}
+			params[param.name] = value
+		case "float":
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, false
+			}
+			params[param.name] = value
+		case "bool":
+			params[param.name] = raw == "true"
+		default:
+			params[param.name] = raw
+		}
+	}
+	return params, true
+}
+
+// CallbackRoute регистрирует обработчик нажатий inline-кнопок, callback_data
+// которых подходит под pattern. Параметры записываются как {name} или {name:type},
+// где type - string, int, float или bool. Если ни обработчик, ни middleware
+// не вызвали c.Answer, на нажатие отвечается автоматически, чтобы кнопка не "зависала".
+// При ErrContinue ответ остаётся следующему обработчику.
+// Паникует, если шаблон некорректен.
+func (b *Bot) CallbackRoute(pattern string, handler func(c *Ctx, params RouteParams) error, middlewares ...Middleware) {
+	route, err := compileRoute(pattern)
+	if err != nil {
+		panic(err)
+	}
+
+	b.handleCallback(func(update Update) bool {
+		if update.CallbackQuery == nil {
+			return false
+		}
+		_, ok := route.match(update.CallbackQuery.Data)
+		return ok
+	}, func(c *Ctx) error {
+		params, _ := route.match(c.Update.CallbackQuery.Data)
+		return handler(c, params)
+	}, middlewares...)
+}
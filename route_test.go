@@ -0,0 +1,103 @@
+package LCB
+
+import (
+	"errors"
+	"testing"
+)
+
+func callbackUpdate(data string) Update {
+	return Update{CallbackQuery: &CallbackQuery{
+		ID:      "1",
+		Data:    data,
+		From:    &User{ID: 1},
+		Message: &Message{Chat: &Chat{ID: 1}},
+	}}
+}
+
+func TestCallbackRouteAnswersWhenMiddlewareShortCircuits(t *testing.T) {
+	api := newStubTelegram(t)
+	b := NewBot("", false)
+
+	deny := func(next HandlerFunc) HandlerFunc {
+		return func(c *Ctx) error {
+			return nil
+		}
+	}
+	b.CallbackRoute("order:{id:int}:cancel", func(c *Ctx, params RouteParams) error {
+		t.Error("handler ran despite the middleware")
+		return nil
+	}, deny)
+
+	b.dispatch(callbackUpdate("order:7:cancel"))
+	b.running.Wait()
+
+	if n := api.count("answerCallbackQuery"); n != 1 {
+		t.Fatalf("answerCallbackQuery called %d times, want 1", n)
+	}
+}
+
+func TestCallbackRouteLeavesAnswerToNextHandlerOnContinue(t *testing.T) {
+	api := newStubTelegram(t)
+	b := NewBot("", false)
+
+	b.CallbackRoute("order:{id:int}", func(c *Ctx, params RouteParams) error {
+		return ErrContinue
+	})
+	b.Group().Handle(func(update Update) bool {
+		return update.CallbackQuery != nil
+	}, func(c *Ctx) error {
+		c.Answer("Заказ отменён", true)
+		return nil
+	})
+
+	b.dispatch(callbackUpdate("order:7"))
+	b.running.Wait()
+
+	if n := api.count("answerCallbackQuery"); n != 1 {
+		t.Fatalf("answerCallbackQuery called %d times, want 1", n)
+	}
+}
+
+func TestCallbackRouteAnswersOnError(t *testing.T) {
+	api := newStubTelegram(t)
+	b := NewBot("", false)
+	b.OnError(func(c *Ctx, err error) {})
+
+	b.CallbackRoute("order:{id:int}", func(c *Ctx, params RouteParams) error {
+		return errors.New("boom")
+	})
+
+	b.dispatch(callbackUpdate("order:7"))
+	b.running.Wait()
+
+	if n := api.count("answerCallbackQuery"); n != 1 {
+		t.Fatalf("answerCallbackQuery called %d times, want 1", n)
+	}
+}
+
+func TestHandleCallbackDataAnswersWhenMiddlewareShortCircuits(t *testing.T) {
+	api := newStubTelegram(t)
+	b := NewBot("", false)
+	codec := NewCallbackCodec("o")
+
+	deny := func(next HandlerFunc) HandlerFunc {
+		return func(c *Ctx) error {
+			return nil
+		}
+	}
+	HandleCallbackData(b, codec, func(c *Ctx, id int64) error {
+		t.Error("handler ran despite the middleware")
+		return nil
+	}, deny)
+
+	data, err := codec.Encode(int64(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.dispatch(callbackUpdate(data))
+	b.running.Wait()
+
+	if n := api.count("answerCallbackQuery"); n != 1 {
+		t.Fatalf("answerCallbackQuery called %d times, want 1", n)
+	}
+}
@@ -0,0 +1,173 @@
+package LCB
+
+import (
+	"strings"
+	"sync"
+)
+
+const scenePrefix = "sc:"
+
+// Scene возвращает текст и клавиатуру экрана меню.
+type Scene func(c *Ctx) (string, *InlineKeyboardMarkup)
+
+// Пример использования
+// menu := bot.Scenes("menu")
+// menu.Add("main", func(c *Ctx) (string, *InlineKeyboardMarkup) {
+// 	markup, _ := NewInlineKeyboard().Add(menu.Button("Настройки", "settings")).Build()
+// 	return "Главное меню", markup
+// })
+// menu.Add("settings", func(c *Ctx) (string, *InlineKeyboardMarkup) {
+// 	markup, _ := NewInlineKeyboard().Add(menu.Button("Язык", "language")).Build()
+// 	return "Настройки", markup
+// })
+// bot.Handle(isMenuCommand, func(c *Ctx) error {
+// 	menu.Open(c, "main")
+// 	return nil
+// })
+
+// SceneManager - система вложенных inline-меню. Кнопки, созданные через Button,
+// переключают экраны, редактируя одно и то же сообщение, а для каждого
+// пользователя в чате хранится стек открытых экранов для кнопки "Назад".
+type SceneManager struct {
+	bot *Bot
+	id  string
+
+	mu     sync.Mutex
+	scenes map[string]Scene
+	stacks map[FSMKey][]string
+	root   string
+
+	// BackText - текст кнопки "Назад", добавляемой на все экраны, кроме первого.
+	// Пустая строка отключает автоматическую кнопку.
+	BackText string
+}
+
+// Scenes создаёт систему меню и регистрирует обработчик её кнопок.
+// id попадает в callback_data и должен быть коротким и уникальным.
+func (b *Bot) Scenes(id string) *SceneManager {
+	m := &SceneManager{
+		bot:      b,
+		id:       id,
+		scenes:   make(map[string]Scene),
+		stacks:   make(map[FSMKey][]string),
+		BackText: "« Назад",
+	}
+	b.handleWidget(m.isScene, m.handle)
+	return m
+}
+
+// Add регистрирует экран name. Первый добавленный экран считается корневым.
+func (m *SceneManager) Add(name string, scene Scene) {
+	m.mu.Lock()
+	m.scenes[name] = scene
+	if m.root == "" {
+		m.root = name
+	}
+	m.mu.Unlock()
+}
+
+// Button создаёт кнопку перехода на экран name.
+func (m *SceneManager) Button(text, name string) InlineKeyboardButton {
+	return InlineCallback(text, m.data(">"+name))
+}
+
+// BackButton создаёт кнопку возврата на предыдущий экран.
+func (m *SceneManager) BackButton(text string) InlineKeyboardButton {
+	return InlineCallback(text, m.data("<"))
+}
+
+// Open отправляет экран name новым сообщением и начинает стек заново.
+// Возвращает ID отправленного сообщения.
+func (m *SceneManager) Open(c *Ctx, name string) int {
+	key, _ := updateKey(c.Update)
+	m.mu.Lock()
+	m.stacks[key] = []string{name}
+	m.mu.Unlock()
+
+	text, markup := m.render(c, name, 1)
+	return c.Reply(text, Utils{Inline: markup})
+}
+
+// Current возвращает имя открытого у пользователя экрана или пустую строку.
+func (m *SceneManager) Current(c *Ctx) string {
+	key, _ := updateKey(c.Update)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stack := m.stacks[key]
+	if len(stack) == 0 {
+		return ""
+	}
+	return stack[len(stack)-1]
+}
+
+func (m *SceneManager) render(c *Ctx, name string, depth int) (string, *InlineKeyboardMarkup) {
+	m.mu.Lock()
+	scene := m.scenes[name]
+	m.mu.Unlock()
+
+	if scene == nil {
+		return "", nil
+	}
+	text, markup := scene(c)
+	if depth > 1 && m.BackText != "" {
+		if markup == nil {
+			markup = &InlineKeyboardMarkup{}
+		}
+		rows := append([][]InlineKeyboardButton(nil), markup.InlineKeyboard...)
+		markup = &InlineKeyboardMarkup{InlineKeyboard: append(rows, []InlineKeyboardButton{m.BackButton(m.BackText)})}
+	}
+	return text, markup
+}
+
+func (m *SceneManager) data(action string) string {
+	return scenePrefix + m.id + ":" + action
+}
+
+func (m *SceneManager) isScene(update Update) bool {
+	return update.CallbackQuery != nil && update.CallbackQuery.Message != nil &&
+		strings.HasPrefix(update.CallbackQuery.Data, scenePrefix+m.id+":")
+}
+
+func (m *SceneManager) handle(c *Ctx) {
+	defer c.answerIfNeeded()
+
+	action := strings.TrimPrefix(c.Update.CallbackQuery.Data, scenePrefix+m.id+":")
+	key, _ := updateKey(c.Update)
+
+	m.mu.Lock()
+	stack := m.stacks[key]
+	switch {
+	case action == "<":
+		if len(stack) > 0 {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 && m.root != "" {
+			stack = []string{m.root}
+		}
+	case strings.HasPrefix(action, ">"):
+		name := action[1:]
+		if _, ok := m.scenes[name]; !ok {
+			m.mu.Unlock()
+			return
+		}
+		// После перезапуска бота стек пуст: считаем, что пользователь пришёл из корня.
+		if len(stack) == 0 && m.root != "" && name != m.root {
+			stack = []string{m.root}
+		}
+		if len(stack) == 0 || stack[len(stack)-1] != name {
+			stack = append(stack, name)
+		}
+	}
+	m.stacks[key] = stack
+	m.mu.Unlock()
+
+	if len(stack) == 0 {
+		return
+	}
+	text, markup := m.render(c, stack[len(stack)-1], len(stack))
+	if markup == nil {
+		markup = &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{}}
+	}
+	c.Edit(text, Utils{Inline: markup})
+}
@@ -0,0 +1,215 @@
+package LCB
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SessionScope определяет, чья это сессия.
+type SessionScope int
+
+const (
+	// SessionPerUser - одна сессия на пользователя во всех чатах.
+	SessionPerUser SessionScope = iota
+	// SessionPerChat - одна сессия на чат, общая для всех его участников.
+	SessionPerChat
+)
+
+// SessionRecord - сохранённая сессия и время последнего обращения к ней.
+type SessionRecord[T any] struct {
+	Data     T
+	Accessed time.Time
+}
+
+// SessionStore хранит сессии по ID пользователя или чата.
+type SessionStore[T any] interface {
+	Load(key int64) (SessionRecord[T], bool, error)
+	Save(key int64, record SessionRecord[T]) error
+	Delete(key int64) error
+}
+
+// SessionConfig задаёт параметры middleware Sessions.
+type SessionConfig[T any] struct {
+	// Store - хранилище сессий. По умолчанию NewState в памяти, из которого
+	// сессии с истёкшим TTL удаляются при обращениях к middleware, не чаще раза
+	// в min(TTL, минута). Своё хранилище само отвечает за удаление.
+	Store SessionStore[T]
+	// New создаёт новую сессию при первом обращении. По умолчанию - нулевое значение T.
+	New   func() T
+	Scope SessionScope
+	// TTL - время бездействия, после которого сессия создаётся заново. 0 - без ограничения.
+	TTL time.Duration
+}
+
+// Пример использования
+// type cart struct {
+// 	Items []string
+// }
+// bot.Use(Sessions(SessionConfig[cart]{TTL: 24 * time.Hour}))
+// bot.Handle(isAddCommand, func(c *Ctx) error {
+// 	session := SessionFrom[cart](c)
+// 	session.Items = append(session.Items, c.Message.Text)
+// 	return nil
+// })
+
+// Sessions возвращает middleware, которое перед обработчиком загружает сессию
+// пользователя или чата, а после него сохраняет её. Обработчики одного ключа
+// выполняются по очереди, чтобы параллельные обновления не затирали сессию.
+// Если обработчик запаниковал, изменения не сохраняются.
+func Sessions[T any](config SessionConfig[T]) Middleware {
+	// sweep удаляет просроченные сессии хранилища по умолчанию. Фоновая горутина
+	// не используется: у middleware нет момента, когда её можно остановить.
+	sweep := func() {}
+	if config.Store == nil {
+		state := NewNewState[int64, SessionRecord[T]]()
+		if config.TTL > 0 {
+			state.SetTTL(config.TTL)
+			interval := min(config.TTL, time.Minute)
+			var lastSweep atomic.Int64
+			lastSweep.Store(time.Now().UnixNano())
+			sweep = func() {
+				last := lastSweep.Load()
+				now := time.Now().UnixNano()
+				if now-last >= int64(interval) && lastSweep.CompareAndSwap(last, now) {
+					state.RemoveExpired()
+				}
+			}
+		}
+		config.Store = NewStateSessionStore(state)
+	}
+	if config.New == nil {
+		config.New = func() T {
+			var zero T
+			return zero
+		}
+	}
+	locks := &keyedMutex{locks: make(map[int64]*keyedLock)}
+	ctxKey := sessionCtxKey[T]()
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Ctx) error {
+			sweep()
+			key, ok := sessionKey(c, config.Scope)
+			if !ok {
+				return next(c)
+			}
+
+			locks.lock(key)
+			defer locks.unlock(key)
+
+			record, found, err := config.Store.Load(key)
+			if err != nil {
+				return err
+			}
+			if !found || (config.TTL > 0 && time.Since(record.Accessed) > config.TTL) {
+				record = SessionRecord[T]{Data: config.New()}
+			}
+
+			data := &record.Data
+			c.Set(ctxKey, data)
+			err = next(c)
+
+			if value, _ := c.Get(ctxKey); value == nil {
+				if deleteErr := config.Store.Delete(key); deleteErr != nil && err == nil {
+					err = deleteErr
+				}
+				return err
+			}
+			record.Accessed = time.Now()
+			if saveErr := config.Store.Save(key, record); saveErr != nil && err == nil {
+				err = saveErr
+			}
+			return err
+		}
+	}
+}
+
+// SessionFrom возвращает сессию текущего обновления. Изменения сохраняются
+// после обработчика. Возвращает nil, если middleware Sessions для T не подключено.
+func SessionFrom[T any](c *Ctx) *T {
+	value, _ := c.Get(sessionCtxKey[T]())
+	session, _ := value.(*T)
+	return session
+}
+
+// ResetSession удаляет сессию текущего обновления после завершения обработчика.
+func ResetSession[T any](c *Ctx) {
+	c.Set(sessionCtxKey[T](), nil)
+}
+
+func sessionCtxKey[T any]() string {
+	return "lcb.session:" + reflect.TypeOf((*T)(nil)).Elem().String()
+}
+
+func sessionKey(c *Ctx, scope SessionScope) (int64, bool) {
+	if scope == SessionPerChat && c.Chat != nil {
+		return c.Chat.ID, true
+	}
+	if scope == SessionPerUser && c.User != nil {
+		return c.User.ID, true
+	}
+	return 0, false
+}
+
+// stateSessionStore хранит сессии в NewState.
+type stateSessionStore[T any] struct {
+	state *NewState[int64, SessionRecord[T]]
+}
+
+// NewStateSessionStore возвращает хранилище сессий поверх NewState.
+func NewStateSessionStore[T any](state *NewState[int64, SessionRecord[T]]) SessionStore[T] {
+	return &stateSessionStore[T]{state: state}
+}
+
+func (s *stateSessionStore[T]) Load(key int64) (SessionRecord[T], bool, error) {
+	record, ok := s.state.GetElementOk(key)
+	return record, ok, nil
+}
+
+func (s *stateSessionStore[T]) Save(key int64, record SessionRecord[T]) error {
+	s.state.SetElement(key, record)
+	return nil
+}
+
+func (s *stateSessionStore[T]) Delete(key int64) error {
+	s.state.DeleteElement(key)
+	return nil
+}
+
+// keyedMutex - набор мьютексов по ключу, которые удаляются, когда не нужны.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[int64]*keyedLock
+}
+
+type keyedLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func (k *keyedMutex) lock(key int64) {
+	k.mu.Lock()
+	l := k.locks[key]
+	if l == nil {
+		l = &keyedLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.mu.Lock()
+}
+
+func (k *keyedMutex) unlock(key int64) {
+	k.mu.Lock()
+	l := k.locks[key]
+	l.refs--
+	if l.refs == 0 {
+		delete(k.locks, key)
+	}
+	k.mu.Unlock()
+
+	l.mu.Unlock()
+}
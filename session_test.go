@@ -0,0 +1,45 @@
+package LCB
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSessionsDefaultStoreStartsNoGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		Sessions(SessionConfig[int]{TTL: time.Hour})
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("Sessions started %d goroutines", after-before)
+	}
+}
+
+func TestSessionsExpire(t *testing.T) {
+	b := NewBot("", false)
+	middleware := Sessions(SessionConfig[int]{TTL: 20 * time.Millisecond})
+
+	visit := func(userID int64) int {
+		c := newCtx(b, Update{Message: &Message{Chat: &Chat{ID: userID}, From: &User{ID: userID}}})
+		var seen int
+		err := middleware(func(c *Ctx) error {
+			counter := SessionFrom[int](c)
+			*counter++
+			seen = *counter
+			return nil
+		})(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return seen
+	}
+
+	if visit(1) != 1 || visit(1) != 2 {
+		t.Fatal("session was not kept between updates")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if n := visit(1); n != 1 {
+		t.Fatalf("expired session was reused: counter = %d", n)
+	}
+}
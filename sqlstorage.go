@@ -0,0 +1,89 @@
+package LCB
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLStorage хранит элементы NewState в таблице (id TEXT PRIMARY KEY, value TEXT)
+// через database/sql, ключи и значения сериализуются в JSON. Запросы написаны для SQLite
+// (плейсхолдеры "?" и upsert через ON CONFLICT), драйвер подключает вызывающий код.
+type SQLStorage[K comparable, T any] struct {
+	db    *sql.DB
+	table string
+}
+
+// Пример использования
+// db, err := sql.Open("sqlite", "bot.db") // например, modernc.org/sqlite
+// storage, err := NewSQLStorage[int64, *User](db, "users")
+// users, err := NewNewStateWithStorage(storage)
+
+// NewSQLStorage создаёт таблицу table, если её нет.
+func NewSQLStorage[K comparable, T any](db *sql.DB, table string) (*SQLStorage[K, T], error) {
+	if !sqlIdentifier.MatchString(table) {
+		return nil, fmt.Errorf("sql storage: invalid table name %q", table)
+	}
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS ` + table + ` (id TEXT PRIMARY KEY, value TEXT NOT NULL)`)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLStorage[K, T]{db: db, table: table}, nil
+}
+
+func (s *SQLStorage[K, T]) Load() (map[K]T, error) {
+	rows, err := s.db.Query(`SELECT id, value FROM ` + s.table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	elements := make(map[K]T)
+	for rows.Next() {
+		var id, value string
+		if err := rows.Scan(&id, &value); err != nil {
+			return nil, err
+		}
+		var key K
+		if err := json.Unmarshal([]byte(id), &key); err != nil {
+			return nil, fmt.Errorf("sql storage: row %s: %w", id, err)
+		}
+		var element T
+		if err := json.Unmarshal([]byte(value), &element); err != nil {
+			return nil, fmt.Errorf("sql storage: row %s: %w", id, err)
+		}
+		elements[key] = element
+	}
+	return elements, rows.Err()
+}
+
+func (s *SQLStorage[K, T]) Set(key K, element T) error {
+	id, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(element)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO `+s.table+` (id, value) VALUES (?, ?)
+		ON CONFLICT(id) DO UPDATE SET value = excluded.value`, string(id), string(value))
+	return err
+}
+
+func (s *SQLStorage[K, T]) Delete(key K) error {
+	id, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`DELETE FROM `+s.table+` WHERE id = ?`, string(id))
+	return err
+}
+
+// Close ничего не делает: соединением с базой управляет вызывающий код.
+func (s *SQLStorage[K, T]) Close() error {
+	return nil
+}
@@ -0,0 +1,191 @@
+package LCB
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Storage сохраняет элементы NewState между перезапусками. Методы вызываются
+// под блокировкой NewState, поэтому порядок записей совпадает с порядком изменений.
+type Storage[K comparable, T any] interface {
+	// Load возвращает все сохранённые элементы.
+	Load() (map[K]T, error)
+	Set(key K, element T) error
+	Delete(key K) error
+	// Close сохраняет несохранённые изменения и освобождает ресурсы.
+	Close() error
+}
+
+// Пример использования
+// storage := NewSnapshotStorage[int64, *User]("users.json", SnapshotJSON, 5*time.Second)
+// users, err := NewNewStateWithStorage(storage)
+// if err != nil {
+// 	log.Fatal(err)
+// }
+// defer users.Close()
+// users.SetElement(user.ID, user)
+
+// SnapshotFormat - формат файла снимка.
+type SnapshotFormat int
+
+const (
+	SnapshotJSON SnapshotFormat = iota
+	SnapshotGob
+)
+
+// SnapshotStorage держит копию состояния в памяти и периодически целиком
+// записывает её в файл. Изменения за последний интервал теряются при падении процесса.
+// SnapshotJSON поддерживает только строковые и целочисленные ключи,
+// для составных ключей (например FSMKey) нужен SnapshotGob.
+type SnapshotStorage[K comparable, T any] struct {
+	path   string
+	format SnapshotFormat
+
+	mu       sync.Mutex
+	elements map[K]T
+	dirty    bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewSnapshotStorage создаёт хранилище, сбрасывающее изменения в path раз в interval.
+func NewSnapshotStorage[K comparable, T any](path string, format SnapshotFormat, interval time.Duration) *SnapshotStorage[K, T] {
+	s := &SnapshotStorage[K, T]{
+		path:     path,
+		format:   format,
+		elements: make(map[K]T),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.flushLoop(interval)
+	return s
+}
+
+func (s *SnapshotStorage[K, T]) Load() (map[K]T, error) {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[K]T{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	elements := make(map[K]T)
+	if s.format == SnapshotGob {
+		err = gob.NewDecoder(file).Decode(&elements)
+	} else {
+		err = json.NewDecoder(file).Decode(&elements)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.elements = make(map[K]T, len(elements))
+	for key, element := range elements {
+		s.elements[key] = element
+	}
+	s.mu.Unlock()
+
+	return elements, nil
+}
+
+func (s *SnapshotStorage[K, T]) Set(key K, element T) error {
+	s.mu.Lock()
+	s.elements[key] = element
+	s.dirty = true
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *SnapshotStorage[K, T]) Delete(key K) error {
+	s.mu.Lock()
+	delete(s.elements, key)
+	s.dirty = true
+	s.mu.Unlock()
+	return nil
+}
+
+// Flush немедленно записывает снимок, если были изменения.
+func (s *SnapshotStorage[K, T]) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.dirty {
+		return nil
+	}
+	err := writeFileAtomic(s.path, func(w io.Writer) error {
+		if s.format == SnapshotGob {
+			return gob.NewEncoder(w).Encode(s.elements)
+		}
+		return json.NewEncoder(w).Encode(s.elements)
+	})
+	if err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+func (s *SnapshotStorage[K, T]) Close() error {
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+		<-s.done
+	}
+	return s.Flush()
+}
+
+func (s *SnapshotStorage[K, T]) flushLoop(interval time.Duration) {
+	defer close(s.done)
+	if interval <= 0 {
+		<-s.stop
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				log.Println("Error writing state snapshot:", err)
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// writeFileAtomic пишет файл через временный файл и переименование,
+// чтобы при сбое не остался наполовину записанный файл.
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
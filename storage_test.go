@@ -0,0 +1,295 @@
+package LCB
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+type storedUser struct {
+	Name string
+	Age  int
+}
+
+// roundTrip записывает элементы через NewState, закрывает его и загружает заново.
+func roundTrip[K comparable](t *testing.T, open func() Storage[K, storedUser], set map[K]storedUser, deleted K) {
+	t.Helper()
+
+	ns, err := NewNewStateWithStorage(open())
+	if err != nil {
+		t.Fatalf("load empty storage: %v", err)
+	}
+	for key, user := range set {
+		ns.SetElement(key, user)
+	}
+	ns.DeleteElement(deleted)
+	if err := ns.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	reloaded, err := NewNewStateWithStorage(open())
+	if err != nil {
+		t.Fatalf("reload storage: %v", err)
+	}
+	defer reloaded.Close()
+
+	want := make(map[K]storedUser)
+	for key, user := range set {
+		if key != deleted {
+			want[key] = user
+		}
+	}
+	if !reflect.DeepEqual(reloaded.State, want) {
+		t.Fatalf("reloaded state = %v, want %v", reloaded.State, want)
+	}
+}
+
+var storedUsers = map[int64]storedUser{
+	1:          {Name: "alice", Age: 30},
+	2:          {Name: "bob", Age: 25},
+	-100123456: {Name: "chat", Age: 0},
+}
+
+func TestSQLStorageRoundTrip(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	roundTrip(t, func() Storage[int64, storedUser] {
+		storage, err := NewSQLStorage[int64, storedUser](db, "users")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return storage
+	}, storedUsers, 2)
+}
+
+func TestSQLStorageUpsertAndCompositeKey(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	storage, err := NewSQLStorage[FSMKey, storedUser](db, "fsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := FSMKey{ChatID: -100, UserID: 7}
+	if err := storage.Set(key, storedUser{Name: "old"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Set(key, storedUser{Name: "new"}); err != nil {
+		t.Fatal(err)
+	}
+
+	elements, err := storage.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(elements) != 1 || elements[key].Name != "new" {
+		t.Fatalf("Load() = %v, want single updated row", elements)
+	}
+
+	if _, err := NewSQLStorage[int64, storedUser](db, "users; DROP TABLE fsm"); err == nil {
+		t.Fatal("invalid table name was accepted")
+	}
+}
+
+func TestWALStorageRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.wal")
+	roundTrip(t, func() Storage[int64, storedUser] {
+		return NewWALStorage[int64, storedUser](path)
+	}, storedUsers, 1)
+}
+
+func TestWALStorageTruncatedTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.wal")
+
+	storage := NewWALStorage[int64, storedUser](path)
+	if _, err := storage.Load(); err != nil {
+		t.Fatal(err)
+	}
+	storage.Set(1, storedUser{Name: "alice"})
+	storage.Set(2, storedUser{Name: "bob"})
+	storage.Close()
+
+	// Имитируем падение посреди записи: последняя строка оборвана.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastLine := bytes.LastIndexByte(data[:len(data)-1], '\n') + 1
+	if err := os.WriteFile(path, data[:lastLine+5], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := NewWALStorage[int64, storedUser](path)
+	elements, err := reloaded.Load()
+	if err != nil {
+		t.Fatalf("Load with truncated tail: %v", err)
+	}
+	if len(elements) != 1 || elements[1].Name != "alice" {
+		t.Fatalf("Load() = %v, want only the complete record", elements)
+	}
+
+	// После восстановления журнал снова пригоден для записи.
+	if err := reloaded.Set(3, storedUser{Name: "carol"}); err != nil {
+		t.Fatal(err)
+	}
+	reloaded.Close()
+
+	again := NewWALStorage[int64, storedUser](path)
+	defer again.Close()
+	elements, err = again.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(elements) != 2 || elements[3].Name != "carol" {
+		t.Fatalf("Load() after recovery = %v", elements)
+	}
+}
+
+func TestWALStorageCorruptRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.wal")
+	if err := os.WriteFile(path, []byte("{not json}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewWALStorage[int64, storedUser](path).Load(); err == nil {
+		t.Fatal("corrupt record in the middle of the log was accepted")
+	}
+}
+
+func TestWALStorageCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.wal")
+
+	storage := NewWALStorage[int64, storedUser](path)
+	storage.CompactMin = 10
+	if _, err := storage.Load(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if err := storage.Set(int64(i%3), storedUser{Name: "user", Age: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	storage.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines >= storage.CompactMin {
+		t.Fatalf("log has %d records after compaction, want fewer than %d", lines, storage.CompactMin)
+	}
+
+	reloaded := NewWALStorage[int64, storedUser](path)
+	defer reloaded.Close()
+	elements, err := reloaded.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int64]storedUser{
+		0: {Name: "user", Age: 99},
+		1: {Name: "user", Age: 97},
+		2: {Name: "user", Age: 98},
+	}
+	if !reflect.DeepEqual(elements, want) {
+		t.Fatalf("Load() after compaction = %v, want %v", elements, want)
+	}
+}
+
+func TestSnapshotStorageRoundTrip(t *testing.T) {
+	for _, format := range []SnapshotFormat{SnapshotJSON, SnapshotGob} {
+		path := filepath.Join(t.TempDir(), "state.snapshot")
+		roundTrip(t, func() Storage[int64, storedUser] {
+			return NewSnapshotStorage[int64, storedUser](path, format, 0)
+		}, storedUsers, -100123456)
+	}
+}
+
+func TestSnapshotStorageGobCompositeKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.gob")
+	roundTrip(t, func() Storage[FSMKey, storedUser] {
+		return NewSnapshotStorage[FSMKey, storedUser](path, SnapshotGob, 0)
+	}, map[FSMKey]storedUser{
+		{ChatID: 1, UserID: 1}:    {Name: "alice"},
+		{ChatID: -100, UserID: 2}: {Name: "bob"},
+	}, FSMKey{ChatID: 1, UserID: 1})
+}
+
+// failingFile дописывает только половину строки и возвращает ошибку.
+type failingFile struct {
+	walFile
+	failTruncate bool
+}
+
+func (f *failingFile) Write(p []byte) (int, error) {
+	n, _ := f.walFile.Write(p[:len(p)/2])
+	return n, errors.New("disk full")
+}
+
+func (f *failingFile) Truncate(size int64) error {
+	if f.failTruncate {
+		return errors.New("read-only file system")
+	}
+	return f.walFile.Truncate(size)
+}
+
+func TestWALStoragePartialWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.wal")
+
+	storage := NewWALStorage[int64, storedUser](path)
+	if _, err := storage.Load(); err != nil {
+		t.Fatal(err)
+	}
+	storage.Set(1, storedUser{Name: "alice"})
+
+	file := storage.file
+	storage.file = &failingFile{walFile: file}
+	if err := storage.Set(2, storedUser{Name: "bob"}); err == nil {
+		t.Fatal("failed write returned no error")
+	}
+	storage.file = file
+	if err := storage.Set(3, storedUser{Name: "carol"}); err != nil {
+		t.Fatal(err)
+	}
+	storage.Close()
+
+	reloaded := NewWALStorage[int64, storedUser](path)
+	elements, err := reloaded.Load()
+	if err != nil {
+		t.Fatalf("Load after failed write: %v", err)
+	}
+	reloaded.Close()
+	if len(elements) != 2 || elements[1].Name != "alice" || elements[3].Name != "carol" {
+		t.Fatalf("Load() = %v", elements)
+	}
+}
+
+func TestWALStorageFailsWhenWriteCannotBeUndone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.wal")
+
+	storage := NewWALStorage[int64, storedUser](path)
+	if _, err := storage.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer storage.Close()
+
+	file := storage.file
+	storage.file = &failingFile{walFile: file, failTruncate: true}
+	storage.Set(1, storedUser{Name: "alice"})
+	storage.file = file
+
+	if err := storage.Set(2, storedUser{Name: "bob"}); err == nil {
+		t.Fatal("write after an unrecoverable failure was accepted")
+	}
+}
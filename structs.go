@@ -1,42 +1,74 @@
 package LCB
 
 import (
+	"context"
 	"sync"
 )
 
 type Bot struct {
 	Token        string
+	Username     string // Заполняется через getMe при Start, если не задан
 	updatesChan  chan Update
-	handlers     []Handler
+	groups       []*Group
+	defaultGroup *Group
+	widgets      *Group
+	middlewares  []Middleware
+	fallback     HandlerFunc
+	errorHandler ErrorHandler
+	fsm          *FSM
+	waiters      map[FSMKey]*waiter
+	waitersMu    sync.Mutex
+	handlersMu   sync.RWMutex
+	dispatcherConfig DispatcherConfig
+	dispatcher   *dispatcher
+	ctx          context.Context
+	cancel       context.CancelFunc
+	running      sync.WaitGroup
+	done         chan struct{}
+	started      bool
 	lastUpdateId int64
-	state        map[int64]interface{}
 	logs         bool
 	Mu           sync.Mutex
 }
 
 func NewBot(token string, logs bool) *Bot {
-	return &Bot{
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &Bot{
 		Token:        token,
+		ctx:          ctx,
+		cancel:       cancel,
+		done:         make(chan struct{}),
 		updatesChan:  make(chan Update),
-		handlers:     []Handler{},
 		lastUpdateId: 0,
-		state:        make(map[int64]interface{}),
 		logs:         logs,
 		Mu:           sync.Mutex{},
 	}
+	b.defaultGroup = &Group{bot: b}
+	b.groups = []*Group{b.defaultGroup}
+	return b
 }
 
+// Utils содержит необязательные параметры отправки сообщения.
+// Из полей Inline, Reply, Delete, ForceReply и Markup может быть задано только одно.
 type Utils struct {
 	Inline       *InlineKeyboardMarkup
 	Reply        *ReplyKeyboardMarkup
 	Delete       *DeleteKeyboard
+	ForceReply   *ForceReply
+	Markup       ReplyMarkup
 	ReplyMessage *int64
 	MessageThreadID *int64
 }
 
+// Handler связывает фильтр с обработчиком. Если задан Func, Callback не используется.
 type Handler struct {
-	Filter   func(update Update) bool
-	Callback func(update Update)
+	Filter      func(update Update) bool
+	Callback    func(update Update)
+	Func        HandlerFunc
+	Middlewares []Middleware
+	// autoAnswer - ответить на нажатие кнопки после обработчика и его middleware,
+	// если никто не ответил сам.
+	autoAnswer bool
 }
 
 // ChatJoinRequest представляет собой запрос на вступление в чат.
@@ -268,6 +300,11 @@ type TelegramResponse struct {
 	Result []Update `json:"result"`
 }
 
+type UserResponse struct {
+	Ok     bool  `json:"ok"`
+	Result *User `json:"result"`
+}
+
 type FileResponse struct {
 	Ok     bool  `json:"ok"`
 	Result *File `json:"result"`
@@ -283,29 +320,150 @@ type InlineKeyboardMarkup struct {
 	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
 }
 
+// InlineKeyboardButton представляет собой кнопку inline-клавиатуры.
+// Кроме Text должно быть заполнено ровно одно поле действия.
 type InlineKeyboardButton struct {
-	Text         string      `json:"text"`
-	URL          string      `json:"url"`
-	CallbackData string      `json:"callback_data"`
-	WebApp       *WebAppInfo `json:"web_app,omitempty"`
+	Text                         string          `json:"text"`
+	URL                          string          `json:"url,omitempty"`
+	CallbackData                 string          `json:"callback_data,omitempty"`
+	WebApp                       *WebAppInfo     `json:"web_app,omitempty"`
+	LoginURL                     *LoginURL       `json:"login_url,omitempty"`
+	SwitchInlineQuery            *string         `json:"switch_inline_query,omitempty"`
+	SwitchInlineQueryCurrentChat *string         `json:"switch_inline_query_current_chat,omitempty"`
+	CopyText                     *CopyTextButton `json:"copy_text,omitempty"`
+	Pay                          bool            `json:"pay,omitempty"`
+}
+
+// LoginURL описывает кнопку авторизации через Telegram Login.
+type LoginURL struct {
+	URL                string `json:"url"`
+	ForwardText        string `json:"forward_text,omitempty"`
+	BotUsername        string `json:"bot_username,omitempty"`
+	RequestWriteAccess bool   `json:"request_write_access,omitempty"`
+}
+
+// CopyTextButton описывает кнопку, копирующую текст в буфер обмена.
+type CopyTextButton struct {
+	Text string `json:"text"`
 }
 
 type ReplyKeyboardMarkup struct {
-	ReplyKeyboard   [][]ReplyKeyboardButton `json:"keyboard"`
-	ResizeKeyboard  bool                    `json:"resize_keyboard"`
-	OneTimeKeyboard bool                    `json:"one_time_keyboard"`
+	ReplyKeyboard         [][]ReplyKeyboardButton `json:"keyboard"`
+	IsPersistent          bool                    `json:"is_persistent,omitempty"`
+	ResizeKeyboard        bool                    `json:"resize_keyboard"`
+	OneTimeKeyboard       bool                    `json:"one_time_keyboard"`
+	InputFieldPlaceholder string                  `json:"input_field_placeholder,omitempty"`
+	Selective             bool                    `json:"selective,omitempty"`
 }
 
+// ReplyKeyboardButton представляет собой кнопку reply-клавиатуры.
+// Кроме Text может быть заполнено не больше одного поля запроса.
 type ReplyKeyboardButton struct {
-	Text string `json:"text"`
+	Text            string                      `json:"text"`
+	RequestUsers    *KeyboardButtonRequestUsers `json:"request_users,omitempty"`
+	RequestChat     *KeyboardButtonRequestChat  `json:"request_chat,omitempty"`
+	RequestContact  bool                        `json:"request_contact,omitempty"`
+	RequestLocation bool                        `json:"request_location,omitempty"`
+	RequestPoll     *KeyboardButtonPollType     `json:"request_poll,omitempty"`
+	WebApp          *WebAppInfo                 `json:"web_app,omitempty"`
+}
+
+// KeyboardButtonPollType ограничивает тип опроса, который может создать пользователь.
+type KeyboardButtonPollType struct {
+	Type string `json:"type,omitempty"` // "quiz", "regular" или пусто для любого типа
+}
+
+// KeyboardButtonRequestUsers описывает запрос на выбор пользователей.
+// Результат приходит в Message.UsersShared с тем же RequestID.
+type KeyboardButtonRequestUsers struct {
+	RequestID       int64 `json:"request_id"`
+	UserIsBot       *bool `json:"user_is_bot,omitempty"`
+	UserIsPremium   *bool `json:"user_is_premium,omitempty"`
+	MaxQuantity     int   `json:"max_quantity,omitempty"` // От 1 до 10, по умолчанию 1
+	RequestName     bool  `json:"request_name,omitempty"`
+	RequestUsername bool  `json:"request_username,omitempty"`
+	RequestPhoto    bool  `json:"request_photo,omitempty"`
+}
+
+// KeyboardButtonRequestChat описывает запрос на выбор чата.
+// Результат приходит в Message.ChatShared с тем же RequestID.
+type KeyboardButtonRequestChat struct {
+	RequestID               int64                    `json:"request_id"`
+	ChatIsChannel           bool                     `json:"chat_is_channel"`
+	ChatIsForum             *bool                    `json:"chat_is_forum,omitempty"`
+	ChatHasUsername         *bool                    `json:"chat_has_username,omitempty"`
+	ChatIsCreated           bool                     `json:"chat_is_created,omitempty"`
+	UserAdministratorRights *ChatAdministratorRights `json:"user_administrator_rights,omitempty"`
+	BotAdministratorRights  *ChatAdministratorRights `json:"bot_administrator_rights,omitempty"`
+	BotIsMember             bool                     `json:"bot_is_member,omitempty"`
+	RequestTitle            bool                     `json:"request_title,omitempty"`
+	RequestUsername         bool                     `json:"request_username,omitempty"`
+	RequestPhoto            bool                     `json:"request_photo,omitempty"`
+}
+
+// ChatAdministratorRights описывает права администратора в чате.
+type ChatAdministratorRights struct {
+	IsAnonymous         bool `json:"is_anonymous"`
+	CanManageChat       bool `json:"can_manage_chat"`
+	CanDeleteMessages   bool `json:"can_delete_messages"`
+	CanManageVideoChats bool `json:"can_manage_video_chats"`
+	CanRestrictMembers  bool `json:"can_restrict_members"`
+	CanPromoteMembers   bool `json:"can_promote_members"`
+	CanChangeInfo       bool `json:"can_change_info"`
+	CanInviteUsers      bool `json:"can_invite_users"`
+	CanPostMessages     bool `json:"can_post_messages,omitempty"`
+	CanEditMessages     bool `json:"can_edit_messages,omitempty"`
+	CanPinMessages      bool `json:"can_pin_messages,omitempty"`
+	CanManageTopics     bool `json:"can_manage_topics,omitempty"`
+}
+
+// UsersShared - служебное сообщение о пользователях, выбранных по кнопке request_users.
+type UsersShared struct {
+	RequestID int64        `json:"request_id"`
+	Users     []SharedUser `json:"users"`
+}
+
+// SharedUser представляет собой пользователя, выбранного по кнопке request_users.
+type SharedUser struct {
+	UserID    int64       `json:"user_id"`
+	FirstName string      `json:"first_name,omitempty"`
+	LastName  string      `json:"last_name,omitempty"`
+	Username  string      `json:"username,omitempty"`
+	Photo     []PhotoSize `json:"photo,omitempty"`
+}
+
+// ChatShared - служебное сообщение о чате, выбранном по кнопке request_chat.
+type ChatShared struct {
+	RequestID int64       `json:"request_id"`
+	ChatID    int64       `json:"chat_id"`
+	Title     string      `json:"title,omitempty"`
+	Username  string      `json:"username,omitempty"`
+	Photo     []PhotoSize `json:"photo,omitempty"`
 }
 
 type WebAppInfo struct {
 	URL string `json:"url"`
 }
 
+// DeleteKeyboard убирает reply-клавиатуру у пользователя.
+// Поле remove_keyboard всегда отправляется как true.
 type DeleteKeyboard struct {
 	RemoveKeyboard bool `json:"remove_keyboard"`
+	Selective      bool `json:"selective,omitempty"`
+}
+
+// ForceReply заставляет клиент показать пользователю интерфейс ответа на сообщение.
+// Поле force_reply всегда отправляется как true.
+type ForceReply struct {
+	ForceReply            bool   `json:"force_reply"`
+	InputFieldPlaceholder string `json:"input_field_placeholder,omitempty"`
+	Selective             bool   `json:"selective,omitempty"`
+}
+
+// ReplyMarkup объединяет все виды разметки, которые можно передать в reply_markup:
+// *InlineKeyboardMarkup, *ReplyKeyboardMarkup, *DeleteKeyboard и *ForceReply.
+type ReplyMarkup interface {
+	isReplyMarkup()
 }
 
 // Update представляет собой одно обновление, получаемое от Telegram.
@@ -370,6 +528,8 @@ type Message struct {
 	PinnedMessage          *Message            `json:"pinned_message,omitempty"`
 	LinkPreviewOptions     *LinkPreviewOptions `json:"link_preview_options,omitempty"`
 	Dice                   *Dice               `json:"dice"`
+	UsersShared            *UsersShared        `json:"users_shared,omitempty"`
+	ChatShared             *ChatShared         `json:"chat_shared,omitempty"`
 }
 
 type LinkPreviewOptions struct {
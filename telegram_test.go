@@ -0,0 +1,53 @@
+package LCB
+
+import (
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// stubTelegram подменяет http.DefaultTransport и записывает вызванные методы Bot API.
+type stubTelegram struct {
+	mu      sync.Mutex
+	methods []string
+}
+
+func newStubTelegram(t *testing.T) *stubTelegram {
+	stub := &stubTelegram{}
+	original := http.DefaultTransport
+	http.DefaultTransport = stub
+	t.Cleanup(func() {
+		http.DefaultTransport = original
+	})
+	return stub
+}
+
+func (s *stubTelegram) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	s.methods = append(s.methods, path.Base(req.URL.Path))
+	s.mu.Unlock()
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"ok":true,"result":{"message_id":1}}`)),
+		Request:    req,
+	}, nil
+}
+
+// count возвращает, сколько раз был вызван метод Bot API.
+func (s *stubTelegram) count(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, m := range s.methods {
+		if m == method {
+			n++
+		}
+	}
+	return n
+}
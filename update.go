@@ -0,0 +1,66 @@
+package LCB
+
+// EffectiveMessage возвращает сообщение, к которому относится обновление:
+// новое или отредактированное сообщение, пост канала или сообщение с нажатой кнопкой.
+func (u Update) EffectiveMessage() *Message {
+	switch {
+	case u.Message != nil:
+		return u.Message
+	case u.EditedMessage != nil:
+		return u.EditedMessage
+	case u.ChannelPost != nil:
+		return u.ChannelPost
+	case u.EditedChannelPost != nil:
+		return u.EditedChannelPost
+	case u.CallbackQuery != nil:
+		return u.CallbackQuery.Message
+	}
+	return nil
+}
+
+// EffectiveChat возвращает чат, в котором произошло обновление, или nil.
+func (u Update) EffectiveChat() *Chat {
+	if message := u.EffectiveMessage(); message != nil && message.Chat != nil {
+		return message.Chat
+	}
+	switch {
+	case u.CallbackQuery != nil && u.CallbackQuery.Chat != nil:
+		return u.CallbackQuery.Chat
+	case u.MyChatMember != nil:
+		return u.MyChatMember.Chat
+	case u.ChatMember != nil:
+		return u.ChatMember.Chat
+	case u.ChatJoinRequest != nil:
+		return u.ChatJoinRequest.Chat
+	}
+	return nil
+}
+
+// EffectiveUser возвращает пользователя, вызвавшего обновление, или nil
+// (например, для постов в канале).
+func (u Update) EffectiveUser() *User {
+	switch {
+	case u.CallbackQuery != nil:
+		return u.CallbackQuery.From
+	case u.InlineQuery != nil:
+		return u.InlineQuery.From
+	case u.ChosenInlineResult != nil:
+		return u.ChosenInlineResult.From
+	case u.ShippingQuery != nil:
+		return u.ShippingQuery.From
+	case u.PreCheckoutQuery != nil:
+		return u.PreCheckoutQuery.From
+	case u.PollAnswer != nil:
+		return u.PollAnswer.From
+	case u.MyChatMember != nil:
+		return u.MyChatMember.From
+	case u.ChatMember != nil:
+		return u.ChatMember.From
+	case u.ChatJoinRequest != nil:
+		return u.ChatJoinRequest.From
+	}
+	if message := u.EffectiveMessage(); message != nil {
+		return message.From
+	}
+	return nil
+}
@@ -39,11 +39,13 @@ func (b *Bot) SendPhoto(chatID int64, photoPathOrFileID string, caption string,
             message["caption"] = caption
         }
 
-		if utils.Reply != nil {
-			message["reply_markup"] = utils.Reply
+		markup, err := utils.replyMarkup()
+		if err != nil {
+			log.Println("Error building reply markup:", err)
+			return 0
 		}
-		if utils.Inline != nil {
-			message["reply_markup"] = utils.Inline
+		if markup != nil {
+			message["reply_markup"] = markup
 		}
 
         messageJSON, err := json.Marshal(message)
@@ -92,10 +94,13 @@ func (b *Bot) SendPhoto(chatID int64, photoPathOrFileID string, caption string,
             }
         }
 
-		if utils.Reply != nil {
-			err = writer.WriteField("reply_markup", serializeKeyboard(utils.Reply))
-		} else if utils.Inline != nil {
-			err = writer.WriteField("reply_markup", serializeKeyboard(utils.Inline))
+		markup, err := utils.replyMarkup()
+		if err != nil {
+			log.Println("Error building reply markup:", err)
+			return 0
+		}
+		if markup != nil {
+			err = writer.WriteField("reply_markup", serializeKeyboard(markup))
 		}
 		if err != nil {
 			log.Println("Error writing keyboard:", err)
@@ -201,6 +206,14 @@ func (b *Bot) SendDice(chatID int64, emoji string, utils Utils) int {
 		"emoji":   emoji,
 	}
 
+	markup, err := utils.replyMarkup()
+	if err != nil {
+		log.Println("Error building reply markup:", err)
+		return 0
+	}
+	if markup != nil {
+		message["reply_markup"] = markup
+	}
 	if utils.ReplyMessage != nil {
 		message["reply_to_message_id"] = *utils.ReplyMessage
 	}
@@ -259,11 +272,13 @@ func (b *Bot) EditMessage(chatID int64, messageID int64, text string, utils Util
 		"parse_mode": "HTML",
 	}
 
-	if utils.Reply != nil {
-		message["reply_markup"] = utils.Reply
+	markup, err := utils.inlineMarkup()
+	if err != nil {
+		log.Println("Error building reply markup:", err)
+		return 0
 	}
-	if utils.Inline != nil {
-		message["reply_markup"] = utils.Inline
+	if markup != nil {
+		message["reply_markup"] = markup
 	}
 
 	if utils.ReplyMessage != nil {
@@ -323,11 +338,13 @@ func (b *Bot) SendMessage(chatID int64, text string, utils Utils) int {
 		"parse_mode": "HTML",
 	}
 
-	if utils.Reply != nil {
-		message["reply_markup"] = utils.Reply
+	markup, err := utils.replyMarkup()
+	if err != nil {
+		log.Println("Error building reply markup:", err)
+		return 0
 	}
-	if utils.Inline != nil {
-		message["reply_markup"] = utils.Inline
+	if markup != nil {
+		message["reply_markup"] = markup
 	}
 	if utils.ReplyMessage != nil {
 		message["reply_to_message_id"] = *utils.ReplyMessage
@@ -411,4 +428,48 @@ func (b *Bot) DownloadFile(fileID string) (io.Reader, error) {
 	}
 
 	return resp2.Body, nil
-}
\ No newline at end of file
+}
+func (b *Bot) GetMe() (*User, error) {
+	resp, err := http.Get("https://api.telegram.org/bot" + b.Token + "/getMe")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var userResponse UserResponse
+	err = json.Unmarshal(body, &userResponse)
+	if err != nil {
+		return nil, err
+	}
+	if !userResponse.Ok || userResponse.Result == nil {
+		return nil, fmt.Errorf("telegram API returned an error")
+	}
+
+	return userResponse.Result, nil
+}
+
+// SendChatAction показывает статус бота в чате, например "typing" или "upload_photo".
+func (b *Bot) SendChatAction(chatID int64, action string) {
+	payload := map[string]interface{}{
+		"chat_id": chatID,
+		"action":  action,
+	}
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		log.Println("Error marshalling message:", err)
+		return
+	}
+
+	resp, err := http.Post("https://api.telegram.org/bot"+b.Token+"/sendChatAction", "application/json", bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		log.Println("Error sending request:", err)
+		return
+	}
+	resp.Body.Close()
+}
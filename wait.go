@@ -0,0 +1,193 @@
+package LCB
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrWaitTimeout возвращается, если ответ не пришёл за отведённое время.
+	ErrWaitTimeout = errors.New("wait timed out")
+	// ErrWaitCancelled возвращается, если бот остановлен или ожидание заменено новым.
+	ErrWaitCancelled = errors.New("wait cancelled")
+)
+
+type waiter struct {
+	filter func(update Update) bool
+	result chan Update
+	cancel chan struct{}
+}
+
+// Пример использования
+// bot.Handle(isSurveyCommand, func(c *Ctx) error {
+// 	name, err := c.Ask("Как вас зовут?", time.Minute)
+// 	if err != nil {
+// 		return err
+// 	}
+// 	age, err := c.AskValid("Сколько вам лет?", time.Minute, func(m *Message) error {
+// 		if _, err := strconv.Atoi(m.Text); err != nil {
+// 			return errors.New("Введите число")
+// 		}
+// 		return nil
+// 	})
+// 	...
+// })
+
+// Wait ждёт следующее обновление от того же пользователя в том же чате, для
+// которого filter вернул true (nil - любое сообщение или нажатие кнопки).
+// Такое обновление не попадает в обычные обработчики. Одновременно у
+// пользователя в чате может быть только одно ожидание: новое отменяет старое.
+func (c *Ctx) Wait(timeout time.Duration, filter func(update Update) bool) (Update, error) {
+	return c.waitAfter(timeout, filter, nil)
+}
+
+// Ask отправляет вопрос и ждёт следующее сообщение пользователя в этом чате.
+func (c *Ctx) Ask(question string, timeout time.Duration, utils ...Utils) (*Message, error) {
+	return c.AskValid(question, timeout, nil, utils...)
+}
+
+// AskValid работает как Ask, но проверяет ответ через validate. Если validate
+// вернул ошибку, её текст отправляется пользователю и ответ ожидается снова.
+// timeout действует на каждый ответ отдельно.
+func (c *Ctx) AskValid(question string, timeout time.Duration, validate func(message *Message) error, utils ...Utils) (*Message, error) {
+	isMessage := func(update Update) bool {
+		return update.Message != nil
+	}
+
+	prompt := question
+	for {
+		update, err := c.waitAfter(timeout, isMessage, func() {
+			c.Reply(prompt, utils...)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if validate != nil {
+			if err := validate(update.Message); err != nil {
+				prompt = err.Error()
+				continue
+			}
+		}
+		return update.Message, nil
+	}
+}
+
+// waitAfter регистрирует ожидание, вызывает before (например, отправку вопроса,
+// чтобы быстрый ответ не потерялся) и ждёт подходящее обновление.
+func (c *Ctx) waitAfter(timeout time.Duration, filter func(update Update) bool, before func()) (Update, error) {
+	key, ok := updateKey(c.Update)
+	if !ok {
+		return Update{}, ErrWaitCancelled
+	}
+	if filter == nil {
+		filter = func(update Update) bool {
+			return update.Message != nil || update.CallbackQuery != nil
+		}
+	}
+
+	w := c.Bot.addWaiter(key, filter)
+	defer c.Bot.removeWaiter(key, w)
+	c.releaseWorker()
+
+	if before != nil {
+		before()
+	}
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	var err error
+	select {
+	case update := <-w.result:
+		return update, nil
+	case <-w.cancel:
+		err = ErrWaitCancelled
+	case <-c.Context().Done():
+		err = ErrWaitCancelled
+	case <-expired:
+		err = ErrWaitTimeout
+	}
+
+	// deliverToWaiter мог уже забрать обновление одновременно с таймаутом или отменой.
+	// После removeWaiter новых не будет, а доставленное отдаём, чтобы оно не потерялось.
+	c.Bot.removeWaiter(key, w)
+	select {
+	case update := <-w.result:
+		return update, nil
+	default:
+		return Update{}, err
+	}
+}
+
+func (b *Bot) addWaiter(key FSMKey, filter func(update Update) bool) *waiter {
+	w := &waiter{filter: filter, result: make(chan Update, 1), cancel: make(chan struct{})}
+
+	b.waitersMu.Lock()
+	if b.waiters == nil {
+		b.waiters = make(map[FSMKey]*waiter)
+	}
+	if previous := b.waiters[key]; previous != nil {
+		close(previous.cancel)
+	}
+	b.waiters[key] = w
+	b.waitersMu.Unlock()
+
+	return w
+}
+
+func (b *Bot) removeWaiter(key FSMKey, w *waiter) {
+	b.waitersMu.Lock()
+	if b.waiters[key] == w {
+		delete(b.waiters, key)
+	}
+	b.waitersMu.Unlock()
+}
+
+// deliverToWaiter отдаёт обновление ожидающему обработчику. Вызывается до
+// обычной обработки, чтобы ответ не застрял в очереди занятого воркера.
+func (b *Bot) deliverToWaiter(update Update) bool {
+	key, ok := updateKey(update)
+	if !ok {
+		return false
+	}
+
+	b.waitersMu.Lock()
+	w := b.waiters[key]
+	b.waitersMu.Unlock()
+
+	// Фильтр вызывается без блокировки и с восстановлением после паники,
+	// так как выполняется в горутине, читающей обновления.
+	if w == nil || !b.matches(newCtx(b, update), w.filter) {
+		return false
+	}
+
+	b.waitersMu.Lock()
+	defer b.waitersMu.Unlock()
+	if b.waiters[key] != w {
+		return false
+	}
+	delete(b.waiters, key)
+	w.result <- update
+	return true
+}
+
+// updateKey возвращает пользователя и чат обновления.
+func updateKey(update Update) (FSMKey, bool) {
+	chat, user := update.EffectiveChat(), update.EffectiveUser()
+	if chat == nil && user == nil {
+		return FSMKey{}, false
+	}
+	var key FSMKey
+	if chat != nil {
+		key.ChatID = chat.ID
+	}
+	if user != nil {
+		key.UserID = user.ID
+	}
+	return key, true
+}
@@ -0,0 +1,106 @@
+package LCB
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// Ответ, доставленный одновременно с отменой ожидания, не должен теряться.
+func TestWaitKeepsUpdateDeliveredOnCancel(t *testing.T) {
+	b := NewBot("", false)
+	question := Update{Message: &Message{Chat: &Chat{ID: 1}, From: &User{ID: 2}, Text: "/ask"}}
+	answer := Update{UpdateID: 7, Message: &Message{Chat: &Chat{ID: 1}, From: &User{ID: 2}, Text: "42"}}
+
+	for i := 0; i < 100; i++ {
+		c := newCtx(b, question)
+		ctx, cancel := context.WithCancel(context.Background())
+		c.SetContext(ctx)
+
+		update, err := c.waitAfter(0, nil, func() {
+			if !b.deliverToWaiter(answer) {
+				t.Fatal("answer was not delivered to the waiter")
+			}
+			cancel()
+		})
+		if err != nil || update.UpdateID != answer.UpdateID {
+			t.Fatalf("waitAfter() = %v, %v, want the delivered answer", update.UpdateID, err)
+		}
+	}
+}
+
+// Обработчик, ждущий ответа, не должен занимать единственный воркер пула:
+// иначе dispatch блокируется на очереди и ответ не доходит до ожидания.
+func TestWaitReleasesDispatcherWorker(t *testing.T) {
+	for _, mode := range []DispatchMode{DispatchParallel, DispatchPerChat} {
+		b := NewBot("", false)
+		b.dispatcher = newDispatcher(DispatcherConfig{Workers: 1, QueueSize: 1, Mode: mode})
+
+		answers := make(chan string, 1)
+		others := make(chan int64, 2)
+		b.Handle(func(update Update) bool {
+			return update.Message != nil && update.Message.Text == "/ask"
+		}, func(c *Ctx) error {
+			update, err := c.Wait(0, nil)
+			if err != nil {
+				answers <- err.Error()
+				return nil
+			}
+			answers <- update.Message.Text
+			return nil
+		})
+		b.Handle(func(update Update) bool {
+			return update.Message != nil
+		}, func(c *Ctx) error {
+			others <- c.Chat.ID
+			return nil
+		})
+
+		message := func(chatID int64, text string) Update {
+			return Update{Message: &Message{Chat: &Chat{ID: chatID}, From: &User{ID: chatID}, Text: text}}
+		}
+
+		dispatched := make(chan struct{})
+		go func() {
+			b.dispatch(message(1, "/ask"))
+			// Дожидаемся, пока обработчик зарегистрирует ожидание.
+			for {
+				b.waitersMu.Lock()
+				n := len(b.waiters)
+				b.waitersMu.Unlock()
+				if n > 0 {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+			b.dispatch(message(2, "hi"))
+			b.dispatch(message(3, "hi"))
+			b.dispatch(message(1, "42"))
+			close(dispatched)
+		}()
+
+		select {
+		case <-dispatched:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("mode %d: dispatch blocked while a handler was waiting", mode)
+		}
+		select {
+		case answer := <-answers:
+			if answer != "42" {
+				t.Fatalf("mode %d: Wait returned %q, want the answer", mode, answer)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("mode %d: answer did not reach the waiting handler", mode)
+		}
+		for i := 0; i < 2; i++ {
+			select {
+			case <-others:
+			case <-time.After(2 * time.Second):
+				t.Fatalf("mode %d: updates from other chats were not handled", mode)
+			}
+		}
+
+		b.dispatcher.close()
+		b.running.Wait()
+	}
+}
@@ -0,0 +1,229 @@
+package LCB
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	walSet    = "set"
+	walDelete = "del"
+)
+
+type walRecord[K comparable, T any] struct {
+	Op    string `json:"op"`
+	Key   K      `json:"key"`
+	Value T      `json:"value"`
+}
+
+// WALStorage записывает каждое изменение строкой JSON в конец файла и при
+// загрузке проигрывает журнал. Когда записей в журнале становится намного
+// больше, чем живых элементов, файл переписывается только текущими значениями.
+type WALStorage[K comparable, T any] struct {
+	path string
+
+	// Sync вызывает fsync после каждой записи: медленнее, но изменения
+	// переживают падение системы, а не только процесса.
+	Sync bool
+	// CompactMin - минимальное число записей в журнале для сжатия.
+	CompactMin int
+	// CompactRatio - во сколько раз записей должно быть больше живых элементов для сжатия.
+	CompactRatio int
+
+	mu       sync.Mutex
+	file     walFile
+	elements map[K]T
+	records  int
+	// failed - журнал не удалось вернуть в целостное состояние после ошибки записи.
+	failed error
+}
+
+// walFile - часть *os.File, которую использует журнал.
+type walFile interface {
+	io.Writer
+	io.Seeker
+	io.Closer
+	Truncate(size int64) error
+	Sync() error
+}
+
+func NewWALStorage[K comparable, T any](path string) *WALStorage[K, T] {
+	return &WALStorage[K, T]{
+		path:         path,
+		CompactMin:   1000,
+		CompactRatio: 2,
+		elements:     make(map[K]T),
+	}
+}
+
+func (s *WALStorage[K, T]) Load() (map[K]T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	s.elements = make(map[K]T)
+	s.records = 0
+	s.failed = nil
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var record walRecord[K, T]
+			if err := json.Unmarshal(line, &record); err != nil || readErr != nil {
+				if readErr == nil {
+					file.Close()
+					return nil, fmt.Errorf("wal %s: corrupt record at offset %d: %w", s.path, offset, err)
+				}
+				// Последняя строка без перевода строки - оборванная запись, отбрасываем её.
+				break
+			}
+			s.apply(record)
+		}
+		offset += int64(len(line))
+
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			file.Close()
+			return nil, readErr
+		}
+	}
+
+	if err := file.Truncate(offset); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if s.file != nil {
+		s.file.Close()
+	}
+	s.file = file
+
+	elements := make(map[K]T, len(s.elements))
+	for key, element := range s.elements {
+		elements[key] = element
+	}
+	return elements, s.compactIfNeeded()
+}
+
+func (s *WALStorage[K, T]) Set(key K, element T) error {
+	return s.write(walRecord[K, T]{Op: walSet, Key: key, Value: element})
+}
+
+func (s *WALStorage[K, T]) Delete(key K) error {
+	return s.write(walRecord[K, T]{Op: walDelete, Key: key})
+}
+
+// Compact переписывает журнал только текущими значениями.
+func (s *WALStorage[K, T]) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.compact()
+}
+
+func (s *WALStorage[K, T]) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *WALStorage[K, T]) write(record walRecord[K, T]) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return fmt.Errorf("wal %s: storage is not loaded or already closed", s.path)
+	}
+	if s.failed != nil {
+		return s.failed
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	offset, err := s.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		// Обрезаем недописанную строку, иначе следующие записи окажутся после
+		// испорченной и Load откажется читать журнал.
+		if truncErr := s.file.Truncate(offset); truncErr != nil {
+			s.failed = fmt.Errorf("wal %s: cannot recover from failed write: %w", s.path, truncErr)
+		}
+		return err
+	}
+	if s.Sync {
+		if err := s.file.Sync(); err != nil {
+			return err
+		}
+	}
+
+	s.apply(record)
+	return s.compactIfNeeded()
+}
+
+func (s *WALStorage[K, T]) apply(record walRecord[K, T]) {
+	s.records++
+	if record.Op == walDelete {
+		delete(s.elements, record.Key)
+		return
+	}
+	s.elements[record.Key] = record.Value
+}
+
+func (s *WALStorage[K, T]) compactIfNeeded() error {
+	if s.records < s.CompactMin || s.records < s.CompactRatio*len(s.elements) {
+		return nil
+	}
+	return s.compact()
+}
+
+func (s *WALStorage[K, T]) compact() error {
+	err := writeFileAtomic(s.path, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		for key, element := range s.elements {
+			if err := encoder.Encode(walRecord[K, T]{Op: walSet, Key: key, Value: element}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if s.file != nil {
+		s.file.Close()
+	}
+	s.file = file
+	s.records = len(s.elements)
+	s.failed = nil
+	return nil
+}